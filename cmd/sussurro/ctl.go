@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cesp99/sussurro/internal/appstate"
+	"github.com/cesp99/sussurro/internal/trigger"
+)
+
+const ctlUsage = `Usage: sussurro ctl [options] <command>
+
+Sends a command to the running Sussurro instance over its control socket
+and prints the reply.
+
+Commands:
+  start, stop, toggle     Control recording
+  press, release          Report the hotkey going down or up (follows hotkey.mode)
+  cancel                  Discard the current recording or transcription
+  handsfree [on|off]      Turn hands-free dictation on or off (toggles without an argument)
+  status                  Print the current state
+  version                 Print the version of the running instance
+  settings                Open the settings window
+  subscribe [rms]         Print events as JSON lines until interrupted
+
+Exit status:
+  0  command succeeded (with --wait: transcript printed)
+  1  command was rejected (e.g. stop while not recording)
+  2  usage error
+  3  Sussurro is not running
+  4  --wait: the recording produced no transcript or the wait timed out
+
+  status exits with the state instead of 0, so scripts need not parse it:
+  0   idle
+  10  recording
+  11  transcribing
+  12  listening (hands-free)
+
+Options:
+`
+
+// ctl exit codes, see ctlUsage.
+const (
+	ctlExitOK         = 0
+	ctlExitRejected   = 1
+	ctlExitUsage      = 2
+	ctlExitNotRunning = 3
+	ctlExitNoResult   = 4
+
+	// status only
+	ctlExitRecording    = 10
+	ctlExitTranscribing = 11
+	ctlExitListening    = 12
+)
+
+// ctlStatusExit maps the state names in a status reply to exit codes.
+var ctlStatusExit = map[string]int{
+	trigger.StateName(appstate.Idle):         ctlExitOK,
+	trigger.StateName(appstate.Recording):    ctlExitRecording,
+	trigger.StateName(appstate.Transcribing): ctlExitTranscribing,
+	trigger.StateName(appstate.Listening):    ctlExitListening,
+}
+
+// runCtl implements the "sussurro ctl" subcommand and returns the process
+// exit code.
+func runCtl(args []string) int {
+	fs := flag.NewFlagSet("ctl", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), ctlUsage)
+		fs.PrintDefaults()
+	}
+	wait := fs.Bool("wait", false, "After start/stop/toggle, block until the transcript is delivered and print it")
+	raw := fs.Bool("raw", false, "With --wait, print the raw transcript instead of the cleaned text")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Maximum time to wait with --wait (0 for no limit)")
+	if err := fs.Parse(args); err != nil {
+		return ctlExitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ctlExitUsage
+	}
+
+	cmd := strings.ToLower(fs.Arg(0))
+	line := strings.Join(append([]string{cmd}, fs.Args()[1:]...), " ")
+
+	if *wait {
+		switch cmd {
+		case trigger.CmdStart, trigger.CmdStop, trigger.CmdToggle:
+		default:
+			fmt.Fprintf(os.Stderr, "Error: --wait only applies to start, stop, and toggle\n")
+			return ctlExitUsage
+		}
+	}
+
+	client, err := trigger.Dial()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Sussurro is not running (%s): %v\n", trigger.SocketPath(), err)
+		return ctlExitNotRunning
+	}
+	defer client.Close()
+
+	if cmd == trigger.CmdSubscribe {
+		return ctlSubscribe(client, line)
+	}
+
+	// Subscribe before sending the command so the result cannot be missed
+	var events *trigger.Client
+	if *wait {
+		events, err = trigger.Dial()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return ctlExitNotRunning
+		}
+		defer events.Close()
+		if _, err := events.Subscribe(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return ctlExitNotRunning
+		}
+		// Skip the initial state snapshot
+		if _, err := events.NextEvent(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return ctlExitNotRunning
+		}
+	}
+
+	reply, err := client.Send(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return ctlExitNotRunning
+	}
+	if !reply.OK {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", reply.State, reply.Detail)
+		return ctlExitRejected
+	}
+	if !*wait {
+		printReply(reply)
+		if cmd == trigger.CmdStatus {
+			if code, ok := ctlStatusExit[reply.State]; ok {
+				return code
+			}
+		}
+		return ctlExitOK
+	}
+
+	if *timeout > 0 {
+		events.SetDeadline(time.Now().Add(*timeout))
+	}
+	ev, err := waitForResult(events)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return ctlExitNoResult
+	}
+	if *raw {
+		fmt.Println(ev.Raw)
+	} else {
+		fmt.Println(ev.Text)
+	}
+	return ctlExitOK
+}
+
+// printReply prints the state, or the detail when the command produced one
+// (e.g. version).
+func printReply(reply trigger.Reply) {
+	if reply.Detail != "" {
+		fmt.Println(reply.Detail)
+		return
+	}
+	fmt.Println(reply.State)
+}
+
+// waitForResult reads events until the current dictation is delivered. The
+// pipeline returning to idle without a result means it was cancelled or
+// discarded (no speech).
+func waitForResult(events *trigger.Client) (trigger.Event, error) {
+	for {
+		ev, err := events.NextEvent()
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return trigger.Event{}, errors.New("timed out waiting for the transcript")
+			}
+			return trigger.Event{}, fmt.Errorf("lost connection to Sussurro: %w", err)
+		}
+		switch {
+		case ev.Event == trigger.EventResult:
+			return ev, nil
+		case ev.Event == trigger.EventState && ev.State == trigger.StateName(appstate.Idle):
+			return trigger.Event{}, errors.New("recording produced no transcript")
+		}
+	}
+}
+
+// ctlSubscribe prints events as JSON lines until the connection closes.
+func ctlSubscribe(client *trigger.Client, line string) int {
+	reply, err := client.Send(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return ctlExitNotRunning
+	}
+	if !reply.OK {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", reply.State, reply.Detail)
+		return ctlExitRejected
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	for {
+		ev, err := client.NextEvent()
+		if err != nil {
+			return ctlExitOK
+		}
+		if err := enc.Encode(ev); err != nil {
+			return ctlExitOK
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/cesp99/sussurro/internal/audio"
+	"github.com/cesp99/sussurro/internal/config"
+)
+
+const devicesUsage = `Usage: sussurro devices [options]
+
+Lists the microphones Sussurro can record from, with their native formats.
+Set audio.device in the config to a device's name, part of its name, or its
+ID to record from it instead of the system default. The device in use is
+marked with "*".
+
+Options:
+`
+
+// runDevices implements the "sussurro devices" subcommand and returns the
+// process exit code.
+func runDevices(args []string) int {
+	fs := flag.NewFlagSet("devices", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), devicesUsage)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "", "Path to configuration file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	devices, err := audio.ListDevices()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(devices) == 0 {
+		fmt.Println("No capture devices found")
+		return 1
+	}
+
+	// Mark the device audio.device selects; a missing config just means
+	// the default
+	selected := ""
+	if cfg, err := config.LoadConfig(*configPath); err == nil && !audio.IsDefaultDevice(cfg.Audio.Device) {
+		d, err := audio.FindDevice(devices, cfg.Audio.Device)
+		switch {
+		case errors.Is(err, audio.ErrDeviceNotFound):
+			fmt.Fprintf(os.Stderr, "Warning: audio.device %q is not connected; the system default is used\n\n", cfg.Audio.Device)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Warning: %v; the system default is used\n\n", err)
+		default:
+			selected = d.ID
+		}
+	}
+
+	printDevices(os.Stdout, devices, selected)
+	return 0
+}
+
+// printDevices lists devices, marking the one with ID selected, or the
+// system default when selected is "".
+func printDevices(w io.Writer, devices []audio.Device, selected string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tNAME\tID")
+	for _, d := range devices {
+		mark := ""
+		if d.ID == selected || (selected == "" && d.IsDefault) {
+			mark = "*"
+		}
+		name := d.Name
+		if d.IsDefault {
+			name += " (default)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", mark, name, d.ID)
+		for _, f := range d.Formats {
+			fmt.Fprintf(tw, "\t  %s\n", f)
+		}
+	}
+	tw.Flush()
+}
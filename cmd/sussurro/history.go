@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cesp99/sussurro/internal/clipboard"
+	"github.com/cesp99/sussurro/internal/history"
+)
+
+const historyUsage = `Usage: sussurro history <command> [options]
+
+Commands:
+  list [-n N]                     Show the most recent dictations (default 20)
+  search [-n N] <text>            Show dictations containing text
+  copy [--raw] <id>               Copy a dictation back to the clipboard
+  export [--format json|csv] [-o file]
+                                  Export all dictations (default: JSON to stdout)
+  clear                           Delete all recorded dictations
+`
+
+// runHistory implements the "sussurro history" subcommand and returns the
+// process exit code.
+func runHistory(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Print(historyUsage)
+		return 0
+	}
+
+	path, err := history.DefaultPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	store, err := history.Open(path, history.Retention{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "list":
+		fs := flag.NewFlagSet("history list", flag.ContinueOnError)
+		n := fs.Int("n", 20, "Number of entries to show (0 for all)")
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		entries, err := store.All()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		printHistory(os.Stdout, lastN(entries, *n))
+
+	case "search":
+		fs := flag.NewFlagSet("history search", flag.ContinueOnError)
+		n := fs.Int("n", 0, "Maximum number of matches to show (0 for all)")
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		query := strings.Join(fs.Args(), " ")
+		if query == "" {
+			fmt.Fprintln(os.Stderr, "Error: search requires a query")
+			return 2
+		}
+		entries, err := store.Search(query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(os.Stderr, "No matching dictations")
+			return 1
+		}
+		printHistory(os.Stdout, lastN(entries, *n))
+
+	case "copy":
+		fs := flag.NewFlagSet("history copy", flag.ContinueOnError)
+		raw := fs.Bool("raw", false, "Copy the raw Whisper transcription instead of the cleaned text")
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "Error: copy requires exactly one entry ID")
+			return 2
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(fs.Arg(0), "#"), 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid entry ID %q\n", fs.Arg(0))
+			return 2
+		}
+		entry, err := store.Get(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		text := entry.Cleaned
+		if *raw {
+			text = entry.Raw
+		}
+		if err := clipboard.Write(text); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Copied entry #%d to the clipboard\n", entry.ID)
+
+	case "export":
+		fs := flag.NewFlagSet("history export", flag.ContinueOnError)
+		format := fs.String("format", "json", "Output format: json or csv")
+		output := fs.String("o", "", "Write to file instead of stdout")
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		entries, err := store.All()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			f, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			defer f.Close()
+			w = f
+		}
+
+		switch strings.ToLower(*format) {
+		case "json":
+			err = history.WriteJSON(w, entries)
+		case "csv":
+			err = history.WriteCSV(w, entries)
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (use json or csv)\n", *format)
+			return 2
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+	case "clear":
+		if err := store.Clear(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println("History cleared")
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown history command: %s\n\n%s", cmd, historyUsage)
+		return 2
+	}
+
+	return 0
+}
+
+// lastN returns the newest n entries (all of them when n <= 0).
+func lastN(entries []history.Entry, n int) []history.Entry {
+	if n > 0 && len(entries) > n {
+		return entries[len(entries)-n:]
+	}
+	return entries
+}
+
+// printHistory writes a one-line-per-entry table, oldest first so the most
+// recent dictation ends up right above the prompt.
+func printHistory(w io.Writer, entries []history.Entry) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, e := range entries {
+		app := e.App
+		if app == "" {
+			app = "-"
+		}
+		fmt.Fprintf(tw, "#%d\t%s\t%s\t%s\n", e.ID, e.Time.Local().Format("2006-01-02 15:04"), app, truncate(e.Cleaned, 80))
+	}
+	tw.Flush()
+}
+
+// truncate shortens s to at most n runes on a single line.
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
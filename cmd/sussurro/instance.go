@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cesp99/sussurro/internal/trigger"
+)
+
+// instanceCommands maps the command-line flags of a second launch to the
+// control socket commands forwarded to the running instance. Launching the
+// UI again without flags brings up the settings window, like re-opening any
+// desktop app.
+func instanceCommands(settings, toggle, noUI bool) []string {
+	var cmds []string
+	if toggle {
+		cmds = append(cmds, trigger.CmdToggle)
+	}
+	if settings || (!toggle && !noUI) {
+		cmds = append(cmds, trigger.CmdSettings)
+	}
+	return cmds
+}
+
+// forwardToInstance sends cmds to the instance that holds the instance lock
+// and returns the exit code for this process.
+func forwardToInstance(cmds []string) int {
+	if len(cmds) == 0 {
+		fmt.Fprintln(os.Stderr, "Sussurro is already running. Use \"sussurro ctl\" to control it.")
+		return 1
+	}
+
+	client, err := trigger.Dial()
+	if err != nil {
+		// The lock is taken before the models load, so the socket may not
+		// be up yet.
+		fmt.Fprintf(os.Stderr, "Sussurro is already running but not accepting commands yet (%v)\n", err)
+		return 1
+	}
+	defer client.Close()
+
+	for _, cmd := range cmds {
+		reply, err := client.Send(cmd)
+		if err == nil && !reply.OK {
+			err = errors.New(reply.Detail)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Sussurro is already running; %s failed: %v\n", cmd, err)
+			return 1
+		}
+	}
+	fmt.Println("Sussurro is already running; request forwarded.")
+	return 0
+}
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/cesp99/sussurro/internal/asr"
 	"github.com/cesp99/sussurro/internal/audio"
 	"github.com/cesp99/sussurro/internal/config"
 	"github.com/cesp99/sussurro/internal/context"
+	"github.com/cesp99/sussurro/internal/history"
 	"github.com/cesp99/sussurro/internal/hotkey"
+	"github.com/cesp99/sussurro/internal/hotkey/grab"
 	"github.com/cesp99/sussurro/internal/injection"
 	"github.com/cesp99/sussurro/internal/llm"
 	"github.com/cesp99/sussurro/internal/logger"
@@ -25,6 +32,20 @@ import (
 )
 
 func main() {
+	// Subcommands run without models, audio capture, or UI.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "history":
+			os.Exit(runHistory(os.Args[2:]))
+		case "transcribe":
+			os.Exit(runTranscribe(os.Args[2:]))
+		case "ctl":
+			os.Exit(runCtl(os.Args[2:]))
+		case "devices":
+			os.Exit(runDevices(os.Args[2:]))
+		}
+	}
+
 	// Peek at --no-ui before deciding whether we need mainthread.Init.
 	// mainthread.Init is needed for golang.design/x/hotkey on X11/macOS in CLI mode.
 	noUI := false
@@ -52,8 +73,24 @@ func run() {
 	noUIFlag := flag.Bool("no-ui", false, "Run in headless CLI mode (no overlay or tray)")
 	whisperFlag := flag.Bool("whisper", false, "Switch Whisper ASR model")
 	wspFlag := flag.Bool("wsp", false, "Switch Whisper ASR model (alias for --whisper)")
+	settingsFlag := flag.Bool("settings", false, "Open the settings window (of the running instance, if any)")
+	toggleFlag := flag.Bool("toggle", false, "Toggle recording in the running instance")
 	flag.Parse()
 
+	// Only one instance per user may own the microphone and the control
+	// socket; a second launch hands its request to the running one.
+	if !*whisperFlag && !*wspFlag {
+		lock, err := trigger.AcquireInstanceLock()
+		switch {
+		case errors.Is(err, trigger.ErrAlreadyRunning):
+			os.Exit(forwardToInstance(instanceCommands(*settingsFlag, *toggleFlag, *noUIFlag)))
+		case err != nil:
+			fmt.Printf("Warning: %v\n", err)
+		default:
+			defer lock.Release()
+		}
+	}
+
 	// Ensure Setup (First Run Experience)
 	if err := setup.EnsureSetup(); err != nil {
 		fmt.Printf("Setup failed: %v\n", err)
@@ -76,8 +113,42 @@ func run() {
 		os.Exit(1)
 	}
 
-	// Initialize Logger
+	injectionMethod, err := injection.ParseMethod(cfg.Injection.Method)
+	if err != nil {
+		fmt.Printf("Invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	onTargetClosed, err := injection.ParseTargetClosed(cfg.Injection.OnTargetClosed)
+	if err != nil {
+		fmt.Printf("Invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	hotkeyBackend, err := hotkey.ParseBackend(cfg.Hotkey.Backend)
+	if err != nil {
+		fmt.Printf("Invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	hotkeyMode, err := hotkey.ParseMode(cfg.Hotkey.Mode)
+	if err != nil {
+		fmt.Printf("Invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	tapThreshold, err := hotkey.ParseTapThreshold(cfg.Hotkey.TapThreshold)
+	if err != nil {
+		fmt.Printf("Invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	bindings, err := hotkey.ConfigBindings(cfg.Hotkey)
+	if err != nil {
+		fmt.Printf("Invalid config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Initialize Logger (on stderr when stdout carries the dictations)
 	log := logger.Init(cfg.App.LogLevel)
+	if injectionMethod == injection.MethodStdout {
+		log = logger.InitWithOutput(cfg.App.LogLevel, os.Stderr)
+	}
 	log.Info("Starting Sussurro", "version", version.Version, "ui", !*noUIFlag)
 
 	// Check if models exist
@@ -103,6 +174,13 @@ func run() {
 		os.Exit(1)
 	}
 	defer audioEngine.Close()
+	if !audio.IsDefaultDevice(cfg.Audio.Device) {
+		if err := audioEngine.SetDevice(cfg.Audio.Device); err != nil {
+			log.Warn("Microphone unavailable, using the system default", "device", cfg.Audio.Device, "error", err)
+		} else {
+			log.Info("Using microphone", "device", audioEngine.DeviceName())
+		}
+	}
 
 	// Initialize ASR Engine
 	asrEngine, err := asr.NewEngine(cfg.Models.ASR.Path, cfg.Models.ASR.Threads, cfg.App.Debug)
@@ -121,13 +199,33 @@ func run() {
 	defer llmEngine.Close()
 
 	// Initialize Injector
-	injector, err := injection.NewInjector()
+	injector, err := injection.NewInjector(cfg.Injection, log)
 	if err != nil {
-		log.Error("Failed to initialize injector", "error", err)
+		log.Error("Failed to initialize injector, falling back to clipboard only", "method", injectionMethod, "error", err)
+		injector, _ = injection.NewInjector(config.InjectionConfig{Method: injection.MethodClipboard}, log)
 	}
+	log.Info("Text injection", "method", injector.Method(), "restore_clipboard", cfg.Injection.RestoreClipboard && injector.Method() == injection.MethodPaste)
 
 	// Initialize and Start Pipeline
-	pipe := pipeline.NewPipeline(audioEngine, asrEngine, llmEngine, ctxProvider, injector, log, cfg.Audio.SampleRate, cfg.Audio.MaxDuration)
+	pipe := pipeline.NewPipeline(audioEngine, asrEngine, llmEngine, ctxProvider, injector, log, audioEngine.SampleRate(), cfg.Audio.MaxDuration)
+
+	if cfg.Models.ASR.Streaming {
+		pipe.SetStreaming(cfg.Models.ASR.PartialInterval, cfg.Models.ASR.CommitWindow)
+	}
+
+	pipe.SetPreRoll(cfg.Audio.PreRoll)
+	pipe.SetHandsFreePause(cfg.HandsFree.Pause)
+	pipe.SetContextAwareCleanup(cfg.Models.LLM.ContextAware)
+	pipe.SetOnTargetClosed(onTargetClosed)
+
+	if cfg.History.Enabled {
+		if store, err := openHistory(cfg.History); err != nil {
+			log.Warn("History disabled", "error", err)
+		} else {
+			pipe.SetHistory(store, filepath.Base(cfg.Models.ASR.Path), filepath.Base(cfg.Models.LLM.Path))
+			log.Debug("Recording history", "path", store.Path())
+		}
+	}
 
 	pipe.SetOnCompletion(func() {
 		log.Debug("Pipeline processing completed")
@@ -139,6 +237,70 @@ func run() {
 	}
 	defer pipe.Stop()
 
+	// The control socket drives recording on Wayland and serves
+	// "sussurro ctl" on every platform.
+	triggerServer, err := trigger.NewServer(log)
+	if err != nil {
+		log.Error("Failed to initialize trigger server", "error", err)
+		os.Exit(1)
+	}
+	defer triggerServer.Stop()
+
+	// Every hotkey backend and the socket's press/release commands go
+	// through one dispatcher so hotkey.mode applies everywhere.
+	keys := hotkey.NewDispatcher(pipe, hotkeyMode, tapThreshold, log)
+	triggerServer.SetHotkey(keys.KeyDown, keys.KeyUp)
+
+	// Raw dictation gets its own dispatcher so its presses and the
+	// dictate binding's do not interfere
+	rawKeys := hotkey.NewDispatcher(rawRecorder{pipe}, hotkeyMode, tapThreshold, log)
+
+	// Cancel drops the recording, or the transcription and cleanup under way
+	cancelDictation := func() {
+		if pipe.Cancel() {
+			log.Info("Dictation cancelled")
+		}
+	}
+	// Hands-free dictation listens until turned off again
+	setHandsFree := func(on bool) bool {
+		if !pipe.SetHandsFree(on) {
+			log.Warn("Cannot start hands-free dictation while recording")
+			return false
+		}
+		if on {
+			log.Info("Hands-free dictation on - pause to send each utterance")
+		} else {
+			log.Info("Hands-free dictation off")
+		}
+		return true
+	}
+	actions := hotkey.Actions{
+		hotkey.ActionDictate:    {Down: keys.KeyDown, Up: keys.KeyUp, Abort: keys.KeyAbort},
+		hotkey.ActionDictateRaw: {Down: rawKeys.KeyDown, Up: rawKeys.KeyUp, Abort: rawKeys.KeyAbort},
+		hotkey.ActionCancel:     {Down: cancelDictation},
+		hotkey.ActionReinject: {Down: func() {
+			go func() {
+				if err := pipe.ReinjectLast(); err != nil {
+					log.Warn("Failed to re-inject last result", "error", err)
+				}
+			}()
+		}},
+		hotkey.ActionSettings: {Down: func() {
+			log.Info("Settings are not available in headless mode")
+		}},
+		hotkey.ActionHandsFree: {Down: func() { setHandsFree(!pipe.HandsFree()) }},
+	}
+
+	if err := triggerServer.Start(pipe); err != nil {
+		log.Error("Failed to start trigger server", "error", err)
+		os.Exit(1)
+	}
+	pipe.AddNotifier(triggerServer)
+
+	if *toggleFlag {
+		log.Info("No running instance to toggle; --toggle ignored")
+	}
+
 	// ---- UI mode ----
 	if !*noUIFlag {
 		uiMgr, err := ui.NewManager(cfg)
@@ -147,33 +309,50 @@ func run() {
 			os.Exit(1)
 		}
 
-		pipe.SetUINotifier(uiMgr)
+		pipe.AddNotifier(uiMgr)
+		triggerServer.SetOpenSettings(uiMgr.OpenSettings)
+		uiMgr.SetOnCancel(cancelDictation)
+		uiMgr.SetOnHandsFree(setHandsFree)
+		uiMgr.SetMicrophones(audioEngine.Devices, audioEngine.SetDevice)
+		actions[hotkey.ActionSettings] = hotkey.Callbacks{Down: uiMgr.OpenSettings}
+		if *settingsFlag {
+			uiMgr.OpenSettings()
+		}
 
 		// Set up input handler before entering the UI main loop.
-		if hotkey.IsWayland() {
-			log.Debug("Wayland detected - using trigger server")
-			triggerServer, err := trigger.NewServer(log)
+		if hotkeyBackend == hotkey.BackendEvdev {
+			h, err := registerEvdevHotkey(bindings, actions, log)
 			if err != nil {
-				log.Error("Failed to initialize trigger server", "error", err)
-				os.Exit(1)
-			}
-			defer triggerServer.Stop()
-			if err := triggerServer.Start(
-				func() { log.Debug("Trigger: Starting recording"); pipe.StartRecording() },
-				func() { log.Debug("Trigger: Stopping recording"); pipe.StopRecording() },
-			); err != nil {
-				log.Error("Failed to start trigger server", "error", err)
+				log.Error("Failed to register evdev hotkey", "error", err)
 				os.Exit(1)
 			}
-			log.Warn("Wayland: configure keyboard shortcut (see docs/wayland.md)")
+			defer h.Unregister()
+			uiMgr.SetOnHotkeyChange(func(bindings []hotkey.Binding) {
+				if err := h.SetBindings(bindings); err != nil {
+					log.Warn("Failed to apply new hotkey", "error", err)
+				}
+			})
 		} else {
-			// X11: register hotkey via GDK XGrabKey.
-			// macOS: registered via CGEventTap inside installOverlayHotkey (app_darwin.go).
-			log.Info("Using overlay hotkey")
-			uiMgr.InstallHotkey(cfg.Hotkey.Trigger,
-				func() { log.Info("Listening..."); pipe.StartRecording() },
-				func() { log.Info("Transcribing..."); pipe.StopRecording() },
-			)
+			raw := &rawHotkeys{actions: actions, log: log}
+			defer raw.stop()
+			raw.set(bindings)
+			uiMgr.SetOnHotkeyChange(raw.set)
+
+			if hotkey.IsWayland() {
+				log.Debug("Wayland detected - using GlobalShortcuts portal and trigger server")
+				portal := &portalHotkeys{actions: actions, log: log}
+				defer portal.stop()
+				portal.set(bindings)
+				uiMgr.SetOnHotkeyChange(func(bindings []hotkey.Binding) {
+					raw.set(bindings)
+					portal.set(bindings)
+				})
+			} else {
+				// X11: register hotkeys via GDK XGrabKey.
+				// macOS: registered inside installOverlayHotkeys (app_darwin.go).
+				log.Info("Using overlay hotkeys")
+				uiMgr.InstallHotkeys(bindings, actions)
+			}
 		}
 
 		log.Info("Sussurro UI running")
@@ -184,41 +363,40 @@ func run() {
 	// ---- Headless / CLI mode (--no-ui) ----
 	log.Info("Headless mode — no overlay")
 
-	if hotkey.IsWayland() {
-		log.Debug("Wayland detected - using trigger server")
-
-		triggerServer, err := trigger.NewServer(log)
+	if hotkeyBackend == hotkey.BackendEvdev {
+		h, err := registerEvdevHotkey(bindings, actions, log)
 		if err != nil {
-			log.Error("Failed to initialize trigger server", "error", err)
+			log.Error("Failed to register evdev hotkey", "error", err)
 			os.Exit(1)
 		}
-		defer triggerServer.Stop()
-
-		if err := triggerServer.Start(
-			func() { log.Debug("Trigger: Starting recording"); pipe.StartRecording() },
-			func() { log.Debug("Trigger: Stopping recording"); pipe.StopRecording() },
-		); err != nil {
-			log.Error("Failed to start trigger server", "error", err)
-			os.Exit(1)
+		defer h.Unregister()
+	} else if hotkey.IsWayland() {
+		log.Debug("Wayland detected - using GlobalShortcuts portal and trigger server")
+		chords, _ := hotkey.SplitBindings(bindings)
+		if h := bindPortalShortcut(chords, actions, log); h != nil {
+			defer h.Unregister()
 		}
-		log.Warn("Wayland detected: Configure keyboard shortcut (see docs/wayland.md)")
+		raw := &rawHotkeys{actions: actions, log: log}
+		defer raw.stop()
+		raw.set(bindings)
 	} else {
 		log.Info("Using global hotkeys (X11 / macOS)")
 
-		hkHandler, err := hotkey.NewHandler(cfg.Hotkey.Trigger, log)
+		chords, _ := hotkey.SplitBindings(bindings)
+		hkHandler, err := grab.NewHandler(chords, log)
 		if err != nil {
 			log.Error("Failed to initialize hotkey handler", "error", err)
 			os.Exit(1)
 		}
 		defer hkHandler.Unregister()
 
-		if err := hkHandler.Register(
-			func() { log.Info("Listening..."); pipe.StartRecording() },
-			func() { log.Info("Transcribing..."); pipe.StopRecording() },
-		); err != nil {
+		if err := hkHandler.Register(actions); err != nil {
 			log.Error("Failed to register hotkey", "error", err)
 			os.Exit(1)
 		}
+		raw := &rawHotkeys{actions: actions, log: log}
+		defer raw.stop()
+		raw.set(bindings)
 	}
 
 	log.Info("Sussurro running. Press Ctrl+C to exit.")
@@ -228,3 +406,144 @@ func run() {
 	sig := <-sigChan
 	log.Info("Received signal, shutting down...", "signal", sig)
 }
+
+// bindPortalShortcut binds the hotkey bindings through the Wayland
+// GlobalShortcuts portal. Binding runs in the background because the desktop
+// may ask the user to confirm the shortcuts; the control socket works either
+// way. Returns nil if the session bus is unreachable.
+func bindPortalShortcut(bindings []hotkey.Binding, actions hotkey.Actions, log *slog.Logger) *hotkey.WaylandHandler {
+	if len(bindings) == 0 {
+		return nil
+	}
+	h, err := hotkey.NewWaylandHandler(bindings, log)
+	if err != nil {
+		log.Warn("Wayland: no global shortcut, configure one for \"sussurro ctl\" (see docs/wayland.md)", "error", err)
+		return nil
+	}
+	go func() {
+		if err := h.Register(actions); err != nil {
+			log.Warn("Wayland: no global shortcut, configure one for \"sussurro ctl\" (see docs/wayland.md)", "error", err)
+		}
+	}()
+	return h
+}
+
+// registerEvdevHotkey detects the hotkey bindings by reading keyboards from
+// /dev/input, which works on any Linux compositor.
+func registerEvdevHotkey(bindings []hotkey.Binding, actions hotkey.Actions, log *slog.Logger) (*hotkey.EvdevHandler, error) {
+	log.Info("Using evdev hotkey backend")
+	h, err := hotkey.NewEvdevHandler(bindings, log)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Register(actions); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// rawHotkeys reads the modifier-only and double-tap bindings from
+// /dev/input next to the X11 grabs or portal shortcuts, which cannot
+// express them. The evdev handler is only opened once such a binding exists.
+type rawHotkeys struct {
+	actions hotkey.Actions
+	log     *slog.Logger
+
+	mu sync.Mutex
+	h  *hotkey.EvdevHandler
+}
+
+// set applies the raw bindings among bindings.
+func (r *rawHotkeys) set(bindings []hotkey.Binding) {
+	_, raw := hotkey.SplitBindings(bindings)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.h != nil {
+		if err := r.h.SetBindings(raw); err != nil {
+			r.log.Warn("Failed to apply new hotkey", "error", err)
+		}
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	h, err := registerEvdevHotkey(raw, r.actions, r.log)
+	if err != nil {
+		r.log.Error("Modifier-only and double-tap hotkeys are unavailable", "error", err)
+		return
+	}
+	r.h = h
+}
+
+func (r *rawHotkeys) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.h != nil {
+		r.h.Unregister()
+	}
+}
+
+// portalHotkeys keeps the chord bindings bound through the GlobalShortcuts
+// portal, rebinding them in the same session when they change.
+type portalHotkeys struct {
+	actions hotkey.Actions
+	log     *slog.Logger
+
+	mu sync.Mutex
+	h  *hotkey.WaylandHandler
+}
+
+// set binds the chord bindings among bindings.
+func (p *portalHotkeys) set(bindings []hotkey.Binding) {
+	chords, _ := hotkey.SplitBindings(bindings)
+
+	p.mu.Lock()
+	h := p.h
+	if h == nil {
+		p.h = bindPortalShortcut(chords, p.actions, p.log)
+	}
+	p.mu.Unlock()
+	if h == nil {
+		return
+	}
+	// Blocks while the desktop asks the user to confirm the new keys
+	if err := h.SetBindings(chords); err != nil {
+		p.log.Warn("Failed to apply new hotkey", "error", err)
+	}
+}
+
+func (p *portalHotkeys) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.h != nil {
+		p.h.Unregister()
+	}
+}
+
+// rawRecorder drives the pipeline for raw dictation, which skips LLM
+// cleanup.
+type rawRecorder struct {
+	*pipeline.Pipeline
+}
+
+func (r rawRecorder) StartRecording() bool {
+	return r.StartRawRecording()
+}
+
+// openHistory opens the history store with the retention policy from config.
+func openHistory(cfg config.HistoryConfig) (*history.Store, error) {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	retention := history.Retention{MaxEntries: cfg.MaxEntries}
+	if cfg.MaxAge != "" {
+		d, err := time.ParseDuration(cfg.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid history.max_age %q: %w", cfg.MaxAge, err)
+		}
+		retention.MaxAge = d
+	}
+	return history.Open(path, retention)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cesp99/sussurro/internal/asr"
+	"github.com/cesp99/sussurro/internal/audio"
+	"github.com/cesp99/sussurro/internal/config"
+	"github.com/cesp99/sussurro/internal/llm"
+)
+
+const transcribeUsage = `Usage: sussurro transcribe [options] <file.wav | ->
+
+Runs an audio file through Whisper and the cleanup LLM and prints the result.
+Use "-" to read from stdin: WAV is detected automatically, anything else is
+treated as raw PCM described by --format, --rate, and --channels.
+
+Options:
+`
+
+// transcribeResult is the --output json payload.
+type transcribeResult struct {
+	File       string  `json:"file"`
+	Raw        string  `json:"raw"`
+	Cleaned    string  `json:"cleaned,omitempty"`
+	AudioSecs  float64 `json:"audio_seconds"`
+	SampleRate int     `json:"source_sample_rate"`
+	Channels   int     `json:"source_channels"`
+	ASRModel   string  `json:"asr_model"`
+	LLMModel   string  `json:"llm_model,omitempty"`
+	ASRMS      int64   `json:"asr_ms"`
+	LLMMS      int64   `json:"llm_ms,omitempty"`
+}
+
+// runTranscribe implements the "sussurro transcribe" subcommand and returns
+// the process exit code.
+func runTranscribe(args []string) int {
+	fs := flag.NewFlagSet("transcribe", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), transcribeUsage)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "", "Path to configuration file")
+	output := fs.String("output", "text", "What to print: text (cleaned), raw (skip the LLM), or json")
+	rawFormat := fs.String("format", string(audio.FormatS16LE), "Sample format of raw PCM input: s16le or f32le")
+	rawRate := fs.Int("rate", audio.WhisperSampleRate, "Sample rate of raw PCM input")
+	rawChannels := fs.Int("channels", 1, "Channel count of raw PCM input")
+	debug := fs.Bool("debug", false, "Show model loading and inference logs")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	mode := strings.ToLower(*output)
+	if mode != "text" && mode != "raw" && mode != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown output %q (use text, raw, or json)\n", *output)
+		return 2
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	// Decode and convert the audio before loading any model so that bad
+	// input fails fast.
+	name := fs.Arg(0)
+	clip, err := readClip(name, audio.SampleFormat(strings.ToLower(*rawFormat)), *rawRate, *rawChannels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	samples := clip.ToWhisper()
+	if len(samples) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no audio samples found")
+		return 1
+	}
+
+	asrEngine, err := asr.NewEngine(cfg.Models.ASR.Path, cfg.Models.ASR.Threads, *debug)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize ASR engine: %v\n", err)
+		return 1
+	}
+	defer asrEngine.Close()
+
+	result := transcribeResult{
+		File:       name,
+		AudioSecs:  clip.Duration(),
+		SampleRate: clip.SampleRate,
+		Channels:   clip.Channels,
+		ASRModel:   filepath.Base(cfg.Models.ASR.Path),
+	}
+
+	start := time.Now()
+	text, err := asrEngine.Transcribe(samples)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ASR failed: %v\n", err)
+		return 1
+	}
+	result.Raw = strings.TrimSpace(text)
+	result.ASRMS = time.Since(start).Milliseconds()
+
+	if mode != "raw" && result.Raw != "" {
+		llmEngine, err := llm.NewEngine(cfg.Models.LLM.Path, cfg.Models.LLM.Threads, cfg.Models.LLM.ContextSize, cfg.Models.LLM.GpuLayers, *debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to initialize LLM engine: %v\n", err)
+			return 1
+		}
+		defer llmEngine.Close()
+
+		start = time.Now()
+		cleaned, err := llmEngine.CleanupText(result.Raw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "LLM cleanup failed, using raw text: %v\n", err)
+			cleaned = result.Raw
+		}
+		result.Cleaned = cleaned
+		result.LLMModel = filepath.Base(cfg.Models.LLM.Path)
+		result.LLMMS = time.Since(start).Milliseconds()
+	}
+
+	switch mode {
+	case "raw":
+		fmt.Println(result.Raw)
+	case "text":
+		fmt.Println(result.Cleaned)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// readClip decodes a WAV file, or stdin when name is "-". Stdin that does not
+// start with a RIFF header is decoded as raw PCM.
+func readClip(name string, format audio.SampleFormat, rate, channels int) (audio.Clip, error) {
+	var r io.Reader
+	if name == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(name)
+		if err != nil {
+			return audio.Clip{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	if string(magic) == "RIFF" {
+		return audio.DecodeWAV(br)
+	}
+	if name != "-" {
+		return audio.Clip{}, fmt.Errorf("%s is not a WAV file (pipe raw PCM through stdin instead)", name)
+	}
+	return audio.DecodeRaw(br, format, rate, channels)
+}
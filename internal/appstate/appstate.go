@@ -0,0 +1,12 @@
+package appstate
+
+// State is what the pipeline is doing. The pipeline reports it, and the
+// hotkey dispatcher, the control socket, and the UI act on it.
+type State int
+
+const (
+	Idle         State = iota
+	Recording          // the hotkey is held or toggled on
+	Transcribing       // recordings are being processed
+	Listening          // hands-free dictation is on
+)
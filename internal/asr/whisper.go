@@ -1,14 +1,21 @@
 package asr
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/cesp99/sussurro/internal/logger"
 	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
 )
 
+// minInputSamples is the shortest input Whisper transcribes: it skips
+// anything under one second of 16 kHz audio, so shorter input is padded with
+// silence.
+const minInputSamples = 16000 * 11 / 10
+
 // Engine handles the Whisper model and transcription
 type Engine struct {
 	model   whisper.Model
@@ -45,13 +52,57 @@ func NewEngine(modelPath string, threads int, debug bool) (*Engine, error) {
 	}, nil
 }
 
+// Segment is a span of transcribed text together with its offsets into the
+// audio that was passed to the engine.
+type Segment struct {
+	Text  string
+	Start time.Duration
+	End   time.Duration
+}
+
 // Transcribe processes the audio samples and returns the text
 func (e *Engine) Transcribe(samples []float32) (string, error) {
+	return e.TranscribeContext(context.Background(), samples)
+}
+
+// TranscribeContext is like Transcribe but gives up when ctx is cancelled.
+func (e *Engine) TranscribeContext(ctx context.Context, samples []float32) (string, error) {
+	segments, err := e.TranscribeSegmentsContext(ctx, samples)
+	if err != nil {
+		return "", err
+	}
+
+	// Concatenate the segments to build the full text
+	var result string
+	for _, segment := range segments {
+		result += segment.Text
+	}
+
+	return result, nil
+}
+
+// TranscribeSegments processes the audio samples and returns the individual
+// segments produced by Whisper, in order. Segment offsets are relative to the
+// start of samples, which lets streaming callers commit a stable prefix.
+func (e *Engine) TranscribeSegments(samples []float32) ([]Segment, error) {
+	return e.TranscribeSegmentsContext(context.Background(), samples)
+}
+
+// TranscribeSegmentsContext is like TranscribeSegments but gives up when ctx
+// is cancelled. Whisper checks before encoding each 30 s window, so a
+// cancelled transcription stops at the next window rather than at once, and
+// one shorter than a window runs to the end. The Go bindings do not expose
+// whisper_full_params.abort_callback, which would stop it mid-window.
+func (e *Engine) TranscribeSegmentsContext(ctx context.Context, samples []float32) ([]Segment, error) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
 	if len(samples) == 0 {
-		return "", nil
+		return nil, nil
+	}
+	// Waiting for the engine may have outlived the caller
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	if !e.debug {
@@ -59,21 +110,38 @@ func (e *Engine) Transcribe(samples []float32) (string, error) {
 		defer cleanup()
 	}
 
-	if err := e.context.Process(samples, nil, nil, nil); err != nil {
-		return "", fmt.Errorf("transcription failed: %w", err)
+	if len(samples) < minInputSamples {
+		padded := make([]float32, minInputSamples)
+		copy(padded, samples)
+		samples = padded
 	}
 
-	// Iterate through segments to build the full text
-	var result string
+	// Returning false from the encoder callback aborts the transcription
+	keepGoing := func() bool { return ctx.Err() == nil }
+	if err := e.context.Process(samples, keepGoing, nil, nil); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("transcription failed: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var segments []Segment
 	for {
 		segment, err := e.context.NextSegment()
 		if err != nil {
 			break // End of segments
 		}
-		result += segment.Text
+		segments = append(segments, Segment{
+			Text:  segment.Text,
+			Start: segment.Start,
+			End:   segment.End,
+		})
 	}
 
-	return result, nil
+	return segments, nil
 }
 
 // Close releases resources
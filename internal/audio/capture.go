@@ -5,21 +5,34 @@ import (
 	"fmt"
 	"math"
 	"sync"
+	"unsafe"
 
 	"github.com/gen2brain/malgo"
 )
 
-// CaptureEngine handles audio recording using malgo (miniaudio)
+// CaptureEngine handles audio recording using malgo (miniaudio). The device
+// is opened at its native sample rate and channel count; the engine downmixes
+// and resamples the audio to the mono output rate itself, since miniaudio's
+// built-in converter is a low-quality linear resampler.
 type CaptureEngine struct {
 	ctx          *malgo.AllocatedContext
 	device       *malgo.Device
-	sampleRate   int
-	channels     int
-	bitDepth     int // Should be 16 for Whisper
+	sampleRate   int // output rate, always mono
 	isRecording  bool
 	mutex        sync.Mutex
-	dataCallback func([]byte)
+	dataCallback func([]float32)
 	rmsCB        func(float32) // optional RMS callback, set via SetRMSCallback
+
+	// Native format of the open device, and the converter to the output
+	// rate. Only the audio thread uses the resampler once the device runs.
+	native    Format
+	resampler *Resampler
+
+	// deviceID is the C copy of the selected device's ID, or nil for the
+	// system default. malgo allocates it; it is kept for the engine's
+	// lifetime since it cannot be freed from Go.
+	deviceID   unsafe.Pointer
+	deviceName string
 }
 
 // SetRMSCallback installs a callback that receives the RMS level of each
@@ -43,9 +56,16 @@ func computeRMS(samples []float32) float32 {
 	return float32(math.Sqrt(sum / float64(len(samples))))
 }
 
-// NewCaptureEngine creates a new engine instance
-// Whisper typically expects 16kHz, 1 channel, 16-bit PCM
+// NewCaptureEngine creates a new engine instance. sampleRate and channels
+// are the audio.sample_rate and audio.channels settings, which describe the
+// audio delivered to Whisper rather than the microphone's format; they are
+// checked with ValidateOutput.
 func NewCaptureEngine(sampleRate, channels int) (*CaptureEngine, error) {
+	sampleRate, err := ValidateOutput(sampleRate, channels)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init audio context: %w", err)
@@ -54,12 +74,95 @@ func NewCaptureEngine(sampleRate, channels int) (*CaptureEngine, error) {
 	return &CaptureEngine{
 		ctx:        ctx,
 		sampleRate: sampleRate,
-		channels:   channels,
-		bitDepth:   16, // Fixed to 16-bit for now
 	}, nil
 }
 
-// StartRecording starts capturing audio and sends data to the provided channel
+// ValidateOutput checks the output format settings and returns the sample
+// rate to deliver. Whisper only accepts 16 kHz mono, so those are the only
+// values allowed; zero means the default. The microphone itself may use any
+// rate and channel count.
+func ValidateOutput(sampleRate, channels int) (int, error) {
+	if sampleRate != 0 && sampleRate != WhisperSampleRate {
+		return 0, fmt.Errorf("audio.sample_rate is %d, but Whisper needs %d; the microphone's native rate is resampled automatically",
+			sampleRate, WhisperSampleRate)
+	}
+	if channels != 0 && channels != 1 {
+		return 0, fmt.Errorf("audio.channels is %d, but Whisper needs mono (1); multi-channel microphones are downmixed automatically",
+			channels)
+	}
+	return WhisperSampleRate, nil
+}
+
+// SampleRate returns the rate of the samples the engine delivers.
+func (e *CaptureEngine) SampleRate() int {
+	return e.sampleRate
+}
+
+// NativeFormat returns the format the open device captures in, before
+// conversion. It is the zero Format while the engine is stopped.
+func (e *CaptureEngine) NativeFormat() Format {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.native
+}
+
+// SetDevice selects the capture device by name or ID (see FindDevice). An
+// empty name or "default" selects the system default. If no device matches,
+// the system default is used and an error wrapping ErrDeviceNotFound is
+// returned so the caller can warn. A running capture switches devices
+// immediately.
+func (e *CaptureEngine) SetDevice(name string) error {
+	var (
+		id    unsafe.Pointer
+		label string
+		err   error
+	)
+	if !IsDefaultDevice(name) {
+		var devices []Device
+		devices, err = e.Devices()
+		if err == nil {
+			var d Device
+			if d, err = FindDevice(devices, name); err == nil {
+				id = d.id.Pointer()
+				label = d.Name
+			}
+		}
+	}
+
+	e.mutex.Lock()
+	e.deviceID = id
+	e.deviceName = label
+	old := e.device
+	e.device = nil
+	e.mutex.Unlock()
+
+	if old == nil {
+		return err
+	}
+	// Uninit waits for the audio thread, whose callback takes the mutex
+	old.Uninit()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.isRecording {
+		if startErr := e.startDevice(e.dataCallback); startErr != nil {
+			e.isRecording = false
+			return startErr
+		}
+	}
+	return err
+}
+
+// DeviceName returns the name of the selected capture device, or "" for the
+// system default.
+func (e *CaptureEngine) DeviceName() string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.deviceName
+}
+
+// StartRecording starts capturing audio and sends mono samples at the output
+// rate to the provided channel
 func (e *CaptureEngine) StartRecording(dataChan chan<- []float32) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
@@ -69,17 +172,7 @@ func (e *CaptureEngine) StartRecording(dataChan chan<- []float32) error {
 	}
 
 	// Define the callback that writes to the channel
-	onData := func(data []byte) {
-		// Convert byte slice to float32 slice
-		// data contains raw F32 samples (4 bytes each)
-		numSamples := len(data) / 4
-		floats := make([]float32, numSamples)
-
-		for i := 0; i < numSamples; i++ {
-			bits := binary.LittleEndian.Uint32(data[i*4 : (i+1)*4])
-			floats[i] = math.Float32frombits(bits)
-		}
-
+	onData := func(floats []float32) {
 		// Invoke RMS callback (non-blocking) if installed
 		e.mutex.Lock()
 		cb := e.rmsCB
@@ -102,30 +195,29 @@ func (e *CaptureEngine) StartRecording(dataChan chan<- []float32) error {
 }
 
 // startDevice initiates the low-level audio stream
-func (e *CaptureEngine) startDevice(onData func([]byte)) error {
+func (e *CaptureEngine) startDevice(onData func([]float32)) error {
 	e.dataCallback = onData
 
+	// Zero channels and rate open the device in its native format; only the
+	// sample format is converted by miniaudio, which is lossless for F32
 	deviceConfig := malgo.DefaultDeviceConfig(malgo.Capture)
 	deviceConfig.Capture.Format = malgo.FormatF32
-	deviceConfig.Capture.Channels = uint32(e.channels)
-	deviceConfig.SampleRate = uint32(e.sampleRate)
+	deviceConfig.Capture.Channels = 0
+	deviceConfig.SampleRate = 0
 	deviceConfig.Alsa.NoMMap = 1 // Common fix for Linux ALSA
+	if e.deviceID != nil {
+		deviceConfig.Capture.DeviceID = e.deviceID
+	}
 
 	var err error
 	// Callback to handle incoming audio data
 	e.device, err = malgo.InitDevice(e.ctx.Context, deviceConfig, malgo.DeviceCallbacks{
 		Data: func(pOutputSample, pInputSamples []byte, framecount uint32) {
-			if e.dataCallback != nil {
-				// We received F32 samples as bytes
-				// Copy them to ensure memory safety
-				if len(pInputSamples) == 0 {
-					return
-				}
-
-				dataCopy := make([]byte, len(pInputSamples))
-				copy(dataCopy, pInputSamples)
-
-				e.dataCallback(dataCopy)
+			if e.dataCallback == nil || len(pInputSamples) == 0 {
+				return
+			}
+			if samples := e.convert(pInputSamples); len(samples) > 0 {
+				e.dataCallback(samples)
 			}
 		},
 	})
@@ -133,6 +225,13 @@ func (e *CaptureEngine) startDevice(onData func([]byte)) error {
 		return fmt.Errorf("failed to init device: %w", err)
 	}
 
+	e.native = Format{
+		SampleFormat: "f32",
+		Channels:     int(e.device.CaptureChannels()),
+		SampleRate:   int(e.device.SampleRate()),
+	}
+	e.resampler = NewResampler(e.native.SampleRate, e.sampleRate)
+
 	err = e.device.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start device: %w", err)
@@ -142,6 +241,20 @@ func (e *CaptureEngine) startDevice(onData func([]byte)) error {
 	return nil
 }
 
+// convert decodes a block of native F32 frames, downmixes it to mono, and
+// resamples it to the output rate. It runs on the audio thread. The result
+// may be empty while the resampler collects lookahead.
+func (e *CaptureEngine) convert(data []byte) []float32 {
+	floats := make([]float32, len(data)/4)
+	for i := range floats {
+		floats[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	if e.native.Channels > 1 {
+		floats = Downmix(floats, e.native.Channels)
+	}
+	return e.resampler.Process(floats)
+}
+
 // Stop halts the stream
 func (e *CaptureEngine) Stop() error {
 	e.mutex.Lock()
@@ -155,6 +268,7 @@ func (e *CaptureEngine) Stop() error {
 		e.device.Uninit()
 		e.device = nil
 	}
+	e.native = Format{}
 	e.isRecording = false
 	return nil
 }
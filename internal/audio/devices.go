@@ -0,0 +1,169 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gen2brain/malgo"
+)
+
+// ErrDeviceNotFound is returned when no capture device matches the
+// configured name or ID.
+var ErrDeviceNotFound = errors.New("capture device not found")
+
+// Device describes an audio capture device.
+type Device struct {
+	ID        string // backend identifier, e.g. a PulseAudio source name
+	Name      string
+	IsDefault bool
+	Formats   []Format // native formats; empty if the backend reports none
+
+	id malgo.DeviceID
+}
+
+// Format is a native capture format of a device.
+type Format struct {
+	SampleFormat string // u8, s16, s24, s32, or f32; "" if any
+	Channels     int    // 0 if any
+	SampleRate   int    // 0 if any
+}
+
+// String formats f like "f32, 2 ch, 48000 Hz".
+func (f Format) String() string {
+	parts := []string{"any format", "any channels", "any rate"}
+	if f.SampleFormat != "" {
+		parts[0] = f.SampleFormat
+	}
+	if f.Channels > 0 {
+		parts[1] = fmt.Sprintf("%d ch", f.Channels)
+	}
+	if f.SampleRate > 0 {
+		parts[2] = fmt.Sprintf("%d Hz", f.SampleRate)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ListDevices returns the capture devices of the system, with their native
+// formats.
+func ListDevices() ([]Device, error) {
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init audio context: %w", err)
+	}
+	defer ctx.Free()
+	return listDevices(ctx.Context)
+}
+
+// Devices returns the capture devices, using the engine's audio context.
+func (e *CaptureEngine) Devices() ([]Device, error) {
+	return listDevices(e.ctx.Context)
+}
+
+func listDevices(ctx malgo.Context) ([]Device, error) {
+	infos, err := ctx.Devices(malgo.Capture)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list capture devices: %w", err)
+	}
+
+	devices := make([]Device, 0, len(infos))
+	for _, info := range infos {
+		// Enumeration leaves out the formats on most backends
+		if full, err := ctx.DeviceInfo(malgo.Capture, info.ID, malgo.Shared); err == nil {
+			info.Formats = full.Formats
+		}
+		d := Device{
+			ID:        deviceIDString(info.ID),
+			Name:      info.Name(),
+			IsDefault: info.IsDefault != 0,
+			id:        info.ID,
+		}
+		for _, f := range info.Formats {
+			d.Formats = append(d.Formats, Format{
+				SampleFormat: formatName(f.Format),
+				Channels:     int(f.Channels),
+				SampleRate:   int(f.SampleRate),
+			})
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// FindDevice returns the device that name refers to: an exact ID, an exact
+// name, or a part of exactly one device's name, compared case-insensitively.
+func FindDevice(devices []Device, name string) (Device, error) {
+	want := strings.TrimSpace(name)
+	for _, d := range devices {
+		if d.ID == want {
+			return d, nil
+		}
+	}
+	for _, d := range devices {
+		if strings.EqualFold(d.Name, want) {
+			return d, nil
+		}
+	}
+
+	var matches []Device
+	for _, d := range devices {
+		if strings.Contains(strings.ToLower(d.Name), strings.ToLower(want)) {
+			matches = append(matches, d)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Device{}, fmt.Errorf("%w: %q", ErrDeviceNotFound, name)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, d := range matches {
+			names[i] = d.Name
+		}
+		return Device{}, fmt.Errorf("capture device %q is ambiguous: %s", name, strings.Join(names, ", "))
+	}
+}
+
+// IsDefaultDevice reports whether name selects the system default device.
+func IsDefaultDevice(name string) bool {
+	name = strings.TrimSpace(name)
+	return name == "" || strings.EqualFold(name, "default")
+}
+
+// deviceIDString renders a device ID. Most backends use a readable string
+// (a PulseAudio source, an ALSA hw name, a Core Audio UID); others are
+// shown in hex.
+func deviceIDString(id malgo.DeviceID) string {
+	end := len(id)
+	for end > 0 && id[end-1] == 0 {
+		end--
+	}
+	if end == 0 {
+		return id.String()
+	}
+	for _, b := range id[:end] {
+		if b < 0x20 || b > 0x7e {
+			return id.String()
+		}
+	}
+	return string(id[:end])
+}
+
+// formatName returns the short name of a malgo sample format.
+func formatName(f malgo.FormatType) string {
+	switch f {
+	case malgo.FormatU8:
+		return "u8"
+	case malgo.FormatS16:
+		return "s16"
+	case malgo.FormatS24:
+		return "s24"
+	case malgo.FormatS32:
+		return "s32"
+	case malgo.FormatF32:
+		return "f32"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,196 @@
+package audio
+
+import "math"
+
+// WhisperSampleRate is the sample rate Whisper models expect.
+const WhisperSampleRate = 16000
+
+// Resampler parameters: the kernel spans resampleZeroCrossings lobes of the
+// sinc on each side and is tabulated at resampleTableRes points per lobe.
+// A Kaiser window with beta 8.6 gives roughly 90 dB of stopband attenuation.
+const (
+	resampleZeroCrossings = 16
+	resampleTableRes      = 256
+	resampleKaiserBeta    = 8.6
+)
+
+// resampleKernel is the tabulated right half of the windowed-sinc kernel,
+// indexed by distance in zero crossings * resampleTableRes.
+var resampleKernel = buildResampleKernel()
+
+func buildResampleKernel() []float64 {
+	n := resampleZeroCrossings*resampleTableRes + 1
+	table := make([]float64, n)
+	norm := besselI0(resampleKaiserBeta)
+	for i := range table {
+		x := float64(i) / resampleTableRes
+		r := x / resampleZeroCrossings
+		window := besselI0(resampleKaiserBeta*math.Sqrt(1-r*r)) / norm
+		table[i] = sinc(x) * window
+	}
+	return table
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// besselI0 is the zeroth-order modified Bessel function of the first kind.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
+
+// Resampler converts a mono stream between sample rates using a band-limited
+// (Kaiser-windowed sinc) interpolator. When downsampling, the cutoff follows
+// the output Nyquist frequency so content above it is filtered instead of
+// aliasing into the speech band.
+//
+// Process may be called repeatedly with consecutive chunks; output is delayed
+// by the filter length until Flush is called at the end of the stream.
+// A Resampler is not safe for concurrent use.
+type Resampler struct {
+	from, to int
+	step     float64 // input samples per output sample
+	cutoff   float64 // relative to the input Nyquist frequency
+	support  float64 // half-width of the kernel in input samples
+
+	buf  []float32 // pending input; buf[0] is absolute input index base
+	base int64
+	next int64 // absolute index of the next output sample
+}
+
+// NewResampler creates a resampler from one sample rate to another.
+func NewResampler(from, to int) *Resampler {
+	cutoff := 1.0
+	if to < from {
+		cutoff = float64(to) / float64(from)
+	}
+	return &Resampler{
+		from:    from,
+		to:      to,
+		step:    float64(from) / float64(to),
+		cutoff:  cutoff,
+		support: resampleZeroCrossings / cutoff,
+	}
+}
+
+// Process consumes the next chunk of input and returns every output sample
+// that can be computed so far.
+func (r *Resampler) Process(in []float32) []float32 {
+	if r.from == r.to {
+		out := make([]float32, len(in))
+		copy(out, in)
+		return out
+	}
+	r.buf = append(r.buf, in...)
+	return r.drain(false)
+}
+
+// Flush returns the remaining output, treating the input as ended.
+func (r *Resampler) Flush() []float32 {
+	if r.from == r.to {
+		return nil
+	}
+	return r.drain(true)
+}
+
+func (r *Resampler) drain(final bool) []float32 {
+	end := r.base + int64(len(r.buf)) // absolute, exclusive
+	var out []float32
+
+	for {
+		t := float64(r.next) * r.step // position of this output in input samples
+		if final {
+			if t >= float64(end) {
+				break
+			}
+		} else if int64(math.Floor(t+r.support)) >= end {
+			break // not enough lookahead yet
+		}
+
+		lo := int64(math.Ceil(t - r.support))
+		hi := int64(math.Floor(t + r.support))
+		if lo < r.base {
+			lo = r.base
+		}
+		if hi >= end {
+			hi = end - 1
+		}
+
+		var sum, weights float64
+		for j := lo; j <= hi; j++ {
+			w := r.kernel(float64(j) - t)
+			sum += w * float64(r.buf[j-r.base])
+			weights += w
+		}
+		if weights != 0 {
+			sum /= weights
+		}
+		out = append(out, float32(sum))
+		r.next++
+	}
+
+	// Drop input that no future output sample can reach
+	keep := int64(math.Ceil(float64(r.next)*r.step - r.support))
+	if drop := keep - r.base; drop > 0 {
+		if drop > int64(len(r.buf)) {
+			drop = int64(len(r.buf))
+		}
+		r.buf = append(r.buf[:0], r.buf[drop:]...)
+		r.base += drop
+	}
+	return out
+}
+
+// kernel evaluates the windowed sinc at an offset of x input samples.
+func (r *Resampler) kernel(x float64) float64 {
+	pos := math.Abs(x) * r.cutoff * resampleTableRes
+	i := int(pos)
+	if i >= len(resampleKernel)-1 {
+		return 0
+	}
+	frac := pos - float64(i)
+	return resampleKernel[i]*(1-frac) + resampleKernel[i+1]*frac
+}
+
+// Resample converts a complete mono signal from one sample rate to another.
+func Resample(in []float32, from, to int) []float32 {
+	if from == to || len(in) == 0 {
+		out := make([]float32, len(in))
+		copy(out, in)
+		return out
+	}
+	r := NewResampler(from, to)
+	out := r.Process(in)
+	return append(out, r.Flush()...)
+}
+
+// Downmix averages interleaved multi-channel audio into a single channel.
+func Downmix(in []float32, channels int) []float32 {
+	if channels <= 1 {
+		out := make([]float32, len(in))
+		copy(out, in)
+		return out
+	}
+	frames := len(in) / channels
+	out := make([]float32, frames)
+	for i := 0; i < frames; i++ {
+		var sum float32
+		for c := 0; c < channels; c++ {
+			sum += in[i*channels+c]
+		}
+		out[i] = sum / float32(channels)
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package audio
+
+// RingBuffer keeps the most recent samples written to it, up to a fixed
+// capacity, overwriting the oldest. It is not safe for concurrent use.
+type RingBuffer struct {
+	buf  []float32
+	head int // index of the next write
+	full bool
+}
+
+// NewRingBuffer creates a ring buffer holding up to size samples. A size of
+// zero creates a buffer that keeps nothing.
+func NewRingBuffer(size int) *RingBuffer {
+	return &RingBuffer{buf: make([]float32, max(size, 0))}
+}
+
+// Write appends samples, dropping the oldest ones once the buffer is full.
+func (r *RingBuffer) Write(samples []float32) {
+	size := len(r.buf)
+	if size == 0 || len(samples) == 0 {
+		return
+	}
+	if len(samples) >= size {
+		copy(r.buf, samples[len(samples)-size:])
+		r.head = 0
+		r.full = true
+		return
+	}
+	n := copy(r.buf[r.head:], samples)
+	if n < len(samples) {
+		copy(r.buf, samples[n:])
+	}
+	next := (r.head + len(samples)) % size
+	if next <= r.head {
+		r.full = true
+	}
+	r.head = next
+}
+
+// Len returns the number of samples held.
+func (r *RingBuffer) Len() int {
+	if r.full {
+		return len(r.buf)
+	}
+	return r.head
+}
+
+// Snapshot returns a copy of the samples held, oldest first.
+func (r *RingBuffer) Snapshot() []float32 {
+	out := make([]float32, 0, r.Len())
+	if r.full {
+		out = append(out, r.buf[r.head:]...)
+	}
+	return append(out, r.buf[:r.head]...)
+}
+
+// Reset empties the buffer.
+func (r *RingBuffer) Reset() {
+	r.head = 0
+	r.full = false
+}
@@ -2,21 +2,298 @@ package audio
 
 import (
 	"math"
+	"math/cmplx"
+	"slices"
+	"time"
 )
 
 // VADParams holds configuration for Voice Activity Detection
 type VADParams struct {
-	SampleRate    int
-	EnergyThresh  float32 // Threshold for RMS energy (e.g., 0.005 for quiet environments)
-	SilenceThresh float32 // Threshold to consider silence (lower than EnergyThresh)
+	SampleRate int
+	Frame      time.Duration // analysis frame length
+
+	SilenceThresh   float32 // RMS below which a frame is never speech
+	SNRThresh       float64 // dB above the noise floor for a frame to be loud enough
+	FlatnessThresh  float64 // spectral flatness (dB) below which a frame is tonal, like voiced speech
+	BandRatioThresh float64 // share of energy in the speech band above which a frame is speech-like
+
+	MinSpeech time.Duration // consecutive speech needed to start an utterance
+	Hangover  time.Duration // silence tolerated inside an utterance before it ends
+	Padding   time.Duration // audio kept around the speech when trimming
 }
 
 // DefaultVADParams returns sensible defaults
 func DefaultVADParams() VADParams {
 	return VADParams{
-		SampleRate:    16000,
-		EnergyThresh:  0.01,
-		SilenceThresh: 0.002,
+		SampleRate:      16000,
+		Frame:           20 * time.Millisecond,
+		SilenceThresh:   0.002,
+		SNRThresh:       9,
+		FlatnessThresh:  -6,
+		BandRatioThresh: 0.7,
+		MinSpeech:       80 * time.Millisecond,
+		Hangover:        300 * time.Millisecond,
+		Padding:         200 * time.Millisecond,
+	}
+}
+
+// Speech band and the range the band ratio is taken over, in Hz. Energy
+// below vadLowHz is mostly mains hum and handling noise.
+const (
+	vadBandLowHz  = 200
+	vadBandHighHz = 3500
+	vadLowHz      = 60
+)
+
+// Noise floor tracking rates, per frame: the floor drops quickly, rises
+// slowly through anything that is not voiced, and creeps up during voiced
+// speech so that a floor set too low cannot keep every frame classed as
+// speech.
+const (
+	noiseFall        = 0.3
+	noiseRiseSilence = 0.05
+	noiseRiseSpeech  = 0.002
+)
+
+// frameFeatures are the measurements a speech decision is based on.
+type frameFeatures struct {
+	rms        float32
+	energyDB   float64
+	flatnessDB float64 // over the speech band; 0 for a perfectly flat spectrum
+	bandRatio  float64 // speech band energy / energy above vadLowHz
+}
+
+// VAD is a frame-based voice activity detector. Each frame is judged on its
+// energy relative to an adaptive noise floor and on two spectral features:
+// voiced speech is tonal (low spectral flatness) and concentrated in the
+// speech band. A run of MinSpeech speech frames starts an utterance, which
+// ends after Hangover of non-speech.
+//
+// Samples may be passed in chunks of any size. A VAD is not safe for
+// concurrent use.
+type VAD struct {
+	params    VADParams
+	frameSize int
+	fftSize   int
+	window    []float64
+	pending   []float32 // samples of the incomplete next frame
+
+	noiseDB float64 // adaptive noise floor
+	primed  bool    // noiseDB has been set
+
+	inSpeech bool
+	run      int // consecutive speech frames while not in an utterance
+	silence  int // consecutive non-speech frames while in an utterance
+
+	onsetFrames int
+	hangFrames  int
+}
+
+// NewVAD creates a detector with the given parameters.
+func NewVAD(p VADParams) *VAD {
+	frameSize := max(int(p.Frame.Seconds()*float64(p.SampleRate)), 1)
+	fftSize := 1
+	for fftSize < frameSize {
+		fftSize *= 2
+	}
+	window := make([]float64, frameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(frameSize))
+	}
+	return &VAD{
+		params:      p,
+		frameSize:   frameSize,
+		fftSize:     fftSize,
+		window:      window,
+		onsetFrames: max(int(p.MinSpeech/p.Frame), 1),
+		hangFrames:  int(p.Hangover / p.Frame),
+	}
+}
+
+// FrameSize returns the number of samples in a frame.
+func (v *VAD) FrameSize() int {
+	return v.frameSize
+}
+
+// InSpeech reports whether the last frame processed was part of an
+// utterance.
+func (v *VAD) InSpeech() bool {
+	return v.inSpeech
+}
+
+// Process analyses the next chunk of samples and returns, for every frame
+// it completes, whether the frame is part of an utterance. An utterance is
+// reported from the frame that completes its first MinSpeech of speech.
+func (v *VAD) Process(samples []float32) []bool {
+	v.pending = append(v.pending, samples...)
+	var out []bool
+	for len(v.pending) >= v.frameSize {
+		out = append(out, v.advance(v.classify(v.analyze(v.pending[:v.frameSize]))))
+		v.pending = v.pending[v.frameSize:]
+	}
+	v.pending = slices.Clip(v.pending)
+	return out
+}
+
+// advance updates the utterance state with the decision for the next frame
+// and returns whether the frame is part of an utterance.
+func (v *VAD) advance(speech bool) bool {
+	if !v.inSpeech {
+		if !speech {
+			v.run = 0
+			return false
+		}
+		v.run++
+		if v.run >= v.onsetFrames {
+			v.inSpeech = true
+			v.silence = 0
+		}
+		return v.inSpeech
+	}
+
+	if speech {
+		v.silence = 0
+	} else if v.silence++; v.silence > v.hangFrames {
+		v.inSpeech = false
+		v.run = 0
+	}
+	return v.inSpeech
+}
+
+// classify decides whether a single frame sounds like speech and updates
+// the noise floor.
+func (v *VAD) classify(f frameFeatures) bool {
+	if !v.primed {
+		v.noiseDB = f.energyDB
+		v.primed = true
+	}
+
+	snr := f.energyDB - v.noiseDB
+	loud := f.rms > v.params.SilenceThresh && snr > v.params.SNRThresh
+	voiced := loud && (f.flatnessDB < v.params.FlatnessThresh || f.bandRatio > v.params.BandRatioThresh)
+	// Fricatives are noise-like, so inside an utterance a frame well above
+	// the floor counts on its energy alone. Noise that merely got louder
+	// cannot start an utterance this way.
+	speech := voiced || (loud && v.inSpeech && snr > 2*v.params.SNRThresh)
+
+	switch {
+	case f.energyDB < v.noiseDB:
+		v.noiseDB += (f.energyDB - v.noiseDB) * noiseFall
+	case voiced:
+		v.noiseDB += (f.energyDB - v.noiseDB) * noiseRiseSpeech
+	default:
+		v.noiseDB += (f.energyDB - v.noiseDB) * noiseRiseSilence
+	}
+	return speech
+}
+
+// analyze measures one frame.
+func (v *VAD) analyze(frame []float32) frameFeatures {
+	f := frameFeatures{rms: ComputeRMS(frame)}
+	f.energyDB = 20 * math.Log10(float64(f.rms)+1e-9)
+
+	buf := make([]complex128, v.fftSize)
+	for i, s := range frame {
+		buf[i] = complex(float64(s)*v.window[i], 0)
+	}
+	fft(buf)
+
+	binHz := float64(v.params.SampleRate) / float64(v.fftSize)
+	var total, band, logSum float64
+	var bins int
+	for k := 1; k <= v.fftSize/2; k++ {
+		hz := float64(k) * binHz
+		if hz < vadLowHz {
+			continue
+		}
+		power := real(buf[k])*real(buf[k]) + imag(buf[k])*imag(buf[k])
+		total += power
+		if hz >= vadBandLowHz && hz <= vadBandHighHz {
+			band += power
+			logSum += math.Log(power + 1e-20)
+			bins++
+		}
+	}
+	if total > 0 {
+		f.bandRatio = band / total
+	}
+	if bins > 0 && band > 0 {
+		geometric := math.Exp(logSum / float64(bins))
+		f.flatnessDB = 10 * math.Log10(geometric/(band/float64(bins)))
+	}
+	return f
+}
+
+// DetectSpeech finds the speech in a complete recording. It returns the
+// samples from the start of the first utterance to the end of the last one,
+// widened by p.Padding on each side, and false if there is no speech.
+func DetectSpeech(samples []float32, p VADParams) (start, end int, ok bool) {
+	v := NewVAD(p)
+	n := len(samples) / v.frameSize
+	if n == 0 {
+		return 0, 0, false
+	}
+
+	features := make([]frameFeatures, n)
+	energies := make([]float64, n)
+	for i := range features {
+		features[i] = v.analyze(samples[i*v.frameSize : (i+1)*v.frameSize])
+		energies[i] = features[i].energyDB
+	}
+	// The whole recording is known, so start from its quiet frames rather
+	// than from whatever the first frame holds
+	slices.Sort(energies)
+	v.noiseDB = energies[n/10]
+	v.primed = true
+
+	first, last := -1, -1
+	for i, f := range features {
+		wasSpeech := v.inSpeech
+		speech := v.classify(f)
+		if !v.advance(speech) {
+			continue
+		}
+		if !wasSpeech && first < 0 {
+			first = i - v.onsetFrames + 1
+		}
+		if speech {
+			last = i
+		}
+	}
+	if first < 0 {
+		return 0, 0, false
+	}
+
+	pad := int(p.Padding.Seconds() * float64(p.SampleRate))
+	start = max(first*v.frameSize-pad, 0)
+	end = min((last+1)*v.frameSize+pad, len(samples))
+	return start, end, true
+}
+
+// fft computes the discrete Fourier transform of x in place. len(x) must be
+// a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
 	}
 }
 
@@ -31,8 +308,3 @@ func ComputeRMS(pcm []float32) float32 {
 	}
 	return float32(math.Sqrt(sum / float64(len(pcm))))
 }
-
-// IsSpeechSimple checks if the buffer RMS is above a threshold
-func IsSpeechSimple(pcm []float32, threshold float32) bool {
-	return ComputeRMS(pcm) > threshold
-}
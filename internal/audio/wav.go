@@ -0,0 +1,198 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// WAV format tags
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatExtensible = 0xFFFE
+)
+
+// SampleFormat identifies the encoding of raw PCM samples.
+type SampleFormat string
+
+const (
+	FormatS16LE SampleFormat = "s16le" // signed 16-bit little-endian
+	FormatF32LE SampleFormat = "f32le" // 32-bit IEEE float little-endian
+)
+
+// Clip is a block of decoded audio. Samples are interleaved when Channels > 1
+// and normalised to [-1.0, 1.0].
+type Clip struct {
+	Samples    []float32
+	SampleRate int
+	Channels   int
+}
+
+// Duration returns the length of the clip in seconds.
+func (c Clip) Duration() float64 {
+	if c.SampleRate == 0 || c.Channels == 0 {
+		return 0
+	}
+	return float64(len(c.Samples)/c.Channels) / float64(c.SampleRate)
+}
+
+// ToWhisper downmixes the clip to mono and resamples it to the 16 kHz rate
+// Whisper expects.
+func (c Clip) ToWhisper() []float32 {
+	return Resample(Downmix(c.Samples, c.Channels), c.SampleRate, WhisperSampleRate)
+}
+
+// DecodeWAV reads a RIFF/WAVE stream. Integer PCM (8/16/24/32-bit) and IEEE
+// float (32/64-bit) data are supported, including WAVE_FORMAT_EXTENSIBLE.
+func DecodeWAV(r io.Reader) (Clip, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return Clip{}, fmt.Errorf("failed to read WAV header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return Clip{}, errors.New("not a RIFF/WAVE file")
+	}
+
+	var (
+		haveFmt    bool
+		format     uint16
+		channels   int
+		sampleRate int
+		bits       int
+	)
+
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if haveFmt {
+				return Clip{}, errors.New("WAV file has no data chunk")
+			}
+			return Clip{}, errors.New("WAV file has no fmt chunk")
+		}
+		id := string(hdr[0:4])
+		size := int64(binary.LittleEndian.Uint32(hdr[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return Clip{}, fmt.Errorf("invalid fmt chunk size %d", size)
+			}
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return Clip{}, fmt.Errorf("failed to read fmt chunk: %w", err)
+			}
+			format = binary.LittleEndian.Uint16(buf[0:2])
+			channels = int(binary.LittleEndian.Uint16(buf[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(buf[4:8]))
+			bits = int(binary.LittleEndian.Uint16(buf[14:16]))
+			if format == wavFormatExtensible && size >= 26 {
+				// The first two bytes of the SubFormat GUID hold the real tag
+				format = binary.LittleEndian.Uint16(buf[24:26])
+			}
+			haveFmt = true
+
+		case "data":
+			if !haveFmt {
+				return Clip{}, errors.New("WAV data chunk appears before fmt chunk")
+			}
+			if channels <= 0 || sampleRate <= 0 {
+				return Clip{}, fmt.Errorf("invalid WAV format: %d channels at %d Hz", channels, sampleRate)
+			}
+			// Streams written to a pipe often carry a 0 or 0xFFFFFFFF size;
+			// read to EOF in that case.
+			var data io.Reader = r
+			if size > 0 && size < math.MaxUint32 {
+				data = io.LimitReader(r, size)
+			}
+			samples, err := decodeSamples(data, format, bits)
+			if err != nil {
+				return Clip{}, err
+			}
+			return Clip{Samples: samples, SampleRate: sampleRate, Channels: channels}, nil
+
+		default:
+			// Skip unknown chunks (LIST, fact, ...); chunks are word aligned
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return Clip{}, fmt.Errorf("failed to skip %q chunk: %w", id, err)
+			}
+		}
+	}
+}
+
+// DecodeRaw reads headerless PCM in the given sample format.
+func DecodeRaw(r io.Reader, format SampleFormat, sampleRate, channels int) (Clip, error) {
+	if sampleRate <= 0 || channels <= 0 {
+		return Clip{}, fmt.Errorf("invalid raw format: %d channels at %d Hz", channels, sampleRate)
+	}
+	var samples []float32
+	var err error
+	switch format {
+	case FormatS16LE:
+		samples, err = decodeSamples(r, wavFormatPCM, 16)
+	case FormatF32LE:
+		samples, err = decodeSamples(r, wavFormatFloat, 32)
+	default:
+		return Clip{}, fmt.Errorf("unsupported raw sample format %q (use %s or %s)", format, FormatS16LE, FormatF32LE)
+	}
+	if err != nil {
+		return Clip{}, err
+	}
+	return Clip{Samples: samples, SampleRate: sampleRate, Channels: channels}, nil
+}
+
+// decodeSamples converts little-endian sample data to normalised float32.
+// A trailing partial sample is ignored.
+func decodeSamples(r io.Reader, format uint16, bits int) ([]float32, error) {
+	width := bits / 8
+	switch {
+	case format == wavFormatPCM && (bits == 8 || bits == 16 || bits == 24 || bits == 32):
+	case format == wavFormatFloat && (bits == 32 || bits == 64):
+	default:
+		return nil, fmt.Errorf("unsupported WAV encoding (format 0x%04x, %d bits)", format, bits)
+	}
+
+	br := bufio.NewReader(r)
+	buf := make([]byte, width*4096)
+	var samples []float32
+	var carry int
+	for {
+		n, err := io.ReadFull(br, buf[carry:])
+		n += carry
+		whole := n - n%width
+		for i := 0; i < whole; i += width {
+			samples = append(samples, decodeSample(buf[i:i+width], format, bits))
+		}
+		carry = copy(buf, buf[whole:n])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read samples: %w", err)
+		}
+	}
+}
+
+func decodeSample(b []byte, format uint16, bits int) float32 {
+	if format == wavFormatFloat {
+		if bits == 64 {
+			return float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+	switch bits {
+	case 8:
+		// 8-bit WAV is unsigned
+		return (float32(b[0]) - 128) / 128.0
+	case 16:
+		return float32(int16(binary.LittleEndian.Uint16(b))) / 32768.0
+	case 24:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return float32(v) / 8388608.0
+	default:
+		return float32(float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648.0)
+	}
+}
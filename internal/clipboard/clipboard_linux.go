@@ -14,7 +14,7 @@ func Write(text string) error {
 	err := clipboard.WriteAll(text)
 	if err != nil {
 		// Provide helpful error message if clipboard tools are missing
-		if os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("XDG_SESSION_TYPE") == "wayland" {
+		if isWayland() {
 			return fmt.Errorf("clipboard failed (Wayland requires wl-clipboard): %w - Install with: sudo pacman -S wl-clipboard", err)
 		}
 		return fmt.Errorf("clipboard failed: %w", err)
@@ -26,10 +26,15 @@ func Write(text string) error {
 func Read() (string, error) {
 	text, err := clipboard.ReadAll()
 	if err != nil {
-		if os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("XDG_SESSION_TYPE") == "wayland" {
+		if isWayland() {
 			return "", fmt.Errorf("clipboard failed (Wayland requires wl-clipboard): %w - Install with: sudo pacman -S wl-clipboard", err)
 		}
 		return "", fmt.Errorf("clipboard failed: %w", err)
 	}
 	return text, nil
 }
+
+// isWayland reports whether we are running in a Wayland session.
+func isWayland() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("XDG_SESSION_TYPE") == "wayland"
+}
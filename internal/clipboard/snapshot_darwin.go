@@ -0,0 +1,84 @@
+//go:build darwin
+
+package clipboard
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+
+#import <Cocoa/Cocoa.h>
+
+// Copies every item on the general pasteboard with all of its types.
+// Returns a retained NSArray that must be released with releaseSnapshot.
+void* takeSnapshot() {
+    @autoreleasepool {
+        NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
+        NSMutableArray *items = [[NSMutableArray alloc] init];
+        for (NSPasteboardItem *item in [pasteboard pasteboardItems]) {
+            NSPasteboardItem *copy = [[NSPasteboardItem alloc] init];
+            for (NSString *type in [item types]) {
+                NSData *data = [item dataForType:type];
+                if (data != nil) {
+                    [copy setData:data forType:type];
+                }
+            }
+            [items addObject:copy];
+            [copy release];
+        }
+        return items;
+    }
+}
+
+// Replaces the pasteboard contents with a snapshot. Returns 0 on failure.
+int restoreSnapshot(void* snapshot) {
+    @autoreleasepool {
+        NSArray *items = (NSArray *)snapshot;
+        NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
+        [pasteboard clearContents];
+        if ([items count] == 0) {
+            return 1;
+        }
+        return [pasteboard writeObjects:items] ? 1 : 0;
+    }
+}
+
+void releaseSnapshot(void* snapshot) {
+    [(NSArray *)snapshot release];
+}
+*/
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// Snapshot is a copy of the clipboard taken by Save. On macOS it keeps every
+// pasteboard item with all of its types (rich text, images, file URLs, ...).
+type Snapshot struct {
+	items unsafe.Pointer
+}
+
+// Save captures the current clipboard contents.
+func Save() (*Snapshot, error) {
+	return &Snapshot{items: C.takeSnapshot()}, nil
+}
+
+// Restore writes the snapshot back to the clipboard. Restoring a snapshot of
+// an empty clipboard clears it.
+func (s *Snapshot) Restore() error {
+	if s.items == nil {
+		return errors.New("clipboard snapshot already released")
+	}
+	if C.restoreSnapshot(s.items) == 0 {
+		return errors.New("failed to restore clipboard")
+	}
+	return nil
+}
+
+// Release frees the copied pasteboard data.
+func (s *Snapshot) Release() {
+	if s.items != nil {
+		C.releaseSnapshot(s.items)
+		s.items = nil
+	}
+}
@@ -0,0 +1,137 @@
+//go:build linux
+
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// maxSnapshotBytes caps how much binary clipboard data a snapshot keeps.
+const maxSnapshotBytes = 32 << 20
+
+// Snapshot is a copy of the clipboard taken by Save.
+//
+// Linux clipboard tools can only offer one MIME type when writing, so a
+// snapshot keeps plain text whenever the clipboard offers it, and otherwise
+// the first binary type (e.g. a copied image).
+type Snapshot struct {
+	mime string // binary MIME type; empty for text
+	data []byte
+	text string
+	ok   bool // false when the clipboard was empty or unreadable
+}
+
+// Save captures the current clipboard contents.
+func Save() (*Snapshot, error) {
+	if mime := binaryTarget(listTargets()); mime != "" {
+		if data, err := readTarget(mime); err == nil && len(data) > 0 {
+			return &Snapshot{mime: mime, data: data, ok: true}, nil
+		}
+	}
+
+	text, err := Read()
+	if err != nil {
+		// Nothing (or nothing we can read) to put back
+		return &Snapshot{}, nil
+	}
+	return &Snapshot{text: text, ok: true}, nil
+}
+
+// Restore writes the snapshot back to the clipboard. An empty snapshot is
+// not restored; the clipboard keeps its current contents.
+func (s *Snapshot) Restore() error {
+	if !s.ok {
+		return nil
+	}
+	if s.mime == "" {
+		return Write(s.text)
+	}
+	return writeTarget(s.mime, s.data)
+}
+
+// Release frees resources held by the snapshot.
+func (s *Snapshot) Release() {}
+
+// textTargets are the targets that mean "plain text is available".
+var textTargets = []string{"text/plain", "UTF8_STRING", "STRING", "TEXT"}
+
+// binaryTarget returns the first non-text MIME type offered, or "" when the
+// clipboard offers text (which Read/Write handle) or nothing usable.
+func binaryTarget(targets []string) string {
+	for _, t := range targets {
+		for _, text := range textTargets {
+			if t == text || strings.HasPrefix(t, text+";") {
+				return ""
+			}
+		}
+	}
+	for _, t := range targets {
+		// Skip X11 meta targets (TARGETS, TIMESTAMP, ...) and text/html
+		// style variants that need a text/plain fallback anyway.
+		if strings.Contains(t, "/") && !strings.HasPrefix(t, "text/") {
+			return t
+		}
+	}
+	return ""
+}
+
+// listTargets returns the MIME types offered by the clipboard owner, or nil
+// if no suitable tool is installed.
+func listTargets() []string {
+	var cmd *exec.Cmd
+	if isWayland() {
+		cmd = exec.Command("wl-paste", "--list-types")
+	} else {
+		cmd = exec.Command("xclip", "-selection", "clipboard", "-t", "TARGETS", "-o")
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+func readTarget(mime string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if isWayland() {
+		cmd = exec.Command("wl-paste", "--no-newline", "--type", mime)
+	} else {
+		cmd = exec.Command("xclip", "-selection", "clipboard", "-t", mime, "-o")
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(stdout, maxSnapshotBytes+1))
+	cmd.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSnapshotBytes {
+		return nil, fmt.Errorf("clipboard %s data exceeds %d bytes", mime, maxSnapshotBytes)
+	}
+	return data, nil
+}
+
+func writeTarget(mime string, data []byte) error {
+	var cmd *exec.Cmd
+	if isWayland() {
+		cmd = exec.Command("wl-copy", "--type", mime)
+	} else {
+		cmd = exec.Command("xclip", "-selection", "clipboard", "-t", mime, "-i")
+	}
+	// Both tools fork to keep serving the selection; leave stdout unset so
+	// Run does not wait on the background process.
+	cmd.Stdin = bytes.NewReader(data)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to restore clipboard (%s): %w", mime, err)
+	}
+	return nil
+}
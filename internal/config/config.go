@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -15,6 +16,8 @@ type Config struct {
 	Models    ModelsConfig    `mapstructure:"models"`
 	Hotkey    HotkeyConfig    `mapstructure:"hotkey"`
 	Injection InjectionConfig `mapstructure:"injection"`
+	HandsFree HandsFreeConfig `mapstructure:"handsfree"`
+	History   HistoryConfig   `mapstructure:"history"`
 }
 
 type AppConfig struct {
@@ -24,11 +27,19 @@ type AppConfig struct {
 }
 
 type AudioConfig struct {
+	// Device is the capture device's name, part of its name, or its ID
+	// (see "sussurro devices"). Empty selects the system default.
+	Device string `mapstructure:"device"`
+
 	SampleRate  int    `mapstructure:"sample_rate"`
 	Channels    int    `mapstructure:"channels"`
 	BitDepth    int    `mapstructure:"bit_depth"`
 	BufferSize  int    `mapstructure:"buffer_size"`
 	MaxDuration string `mapstructure:"max_duration"`
+
+	// PreRoll is how much audio from before the hotkey press is prepended
+	// to each recording, as a duration string. "0" disables it.
+	PreRoll string `mapstructure:"pre_roll"`
 }
 
 type ModelsConfig struct {
@@ -37,9 +48,12 @@ type ModelsConfig struct {
 }
 
 type ASRConfig struct {
-	Path    string `mapstructure:"path"`
-	Type    string `mapstructure:"type"`
-	Threads int    `mapstructure:"threads"`
+	Path            string `mapstructure:"path"`
+	Type            string `mapstructure:"type"`
+	Threads         int    `mapstructure:"threads"`
+	Streaming       bool   `mapstructure:"streaming"`        // transcribe while the hotkey is held
+	PartialInterval string `mapstructure:"partial_interval"` // how often to emit a partial transcript
+	CommitWindow    string `mapstructure:"commit_window"`    // pending audio before a prefix is finalized
 }
 
 type LLMConfig struct {
@@ -47,18 +61,170 @@ type LLMConfig struct {
 	ContextSize int    `mapstructure:"context_size"`
 	GpuLayers   int    `mapstructure:"gpu_layers"`
 	Threads     int    `mapstructure:"threads"`
+	// ContextAware adds the focused app/window to the cleanup prompt.
+	// Off by default so the fine-tuned prompt is used unchanged.
+	ContextAware bool `mapstructure:"context_aware"`
 }
 
 type HotkeyConfig struct {
 	Trigger string `mapstructure:"trigger"`
+
+	// Backend selects how the trigger is detected: "auto" (an X11 grab,
+	// the Wayland GlobalShortcuts portal, or a macOS event tap) or "evdev"
+	// (read keyboards from /dev/input, Linux only).
+	Backend string `mapstructure:"backend"`
+
+	// Mode is "hold" (the default), "toggle", or "latch". In latch mode a
+	// press shorter than TapThreshold (e.g. "300ms") keeps recording on.
+	Mode         string `mapstructure:"mode"`
+	TapThreshold string `mapstructure:"tap_threshold"`
+
+	// Bindings map more triggers to actions, next to Trigger (dictate).
+	Bindings []BindingConfig `mapstructure:"bindings"`
+}
+
+// BindingConfig maps a trigger to an action: dictate, dictate_raw, cancel,
+// reinject, settings, or handsfree.
+type BindingConfig struct {
+	Trigger string `mapstructure:"trigger"`
+	Action  string `mapstructure:"action"`
+}
+
+// HandsFreeConfig configures hands-free dictation, which listens
+// continuously and sends each utterance once the speaker pauses.
+type HandsFreeConfig struct {
+	// Pause is the silence that ends an utterance, as a duration string.
+	Pause string `mapstructure:"pause"`
 }
 
 type InjectionConfig struct {
 	Method string `mapstructure:"method"`
+
+	// RestoreClipboard puts the previous clipboard contents back after a
+	// paste, unless something new was copied in the meantime.
+	RestoreClipboard bool   `mapstructure:"restore_clipboard"`
+	RestoreDelay     string `mapstructure:"restore_delay"` // e.g. "500ms"
+
+	// Keyboard selects how keystrokes are synthesized on Linux: "auto",
+	// "uinput" (a /dev/uinput virtual keyboard), or "tools" (xdotool,
+	// wtype, ydotool). The paste shortcut always uses uinput.
+	Keyboard string `mapstructure:"keyboard"`
+
+	// OnTargetClosed decides what happens when the window focused at the
+	// start of the recording is gone by injection time: "focused" (inject
+	// into the current window, the default), "clipboard", or "discard".
+	OnTargetClosed string `mapstructure:"on_target_closed"`
+
+	// Apps overrides injection per target application. The first rule
+	// matching the focused app wins; built-in terminal rules come last.
+	Apps []AppRule `mapstructure:"apps"`
+}
+
+// AppRule customises injection for the applications listed in Match.
+// Empty fields keep the global behavior.
+type AppRule struct {
+	Match     []string `mapstructure:"match"`      // app names / WM_CLASS values, case-insensitive
+	Method    string   `mapstructure:"method"`     // paste, type, clipboard, stdout, or none
+	PasteKeys string   `mapstructure:"paste_keys"` // e.g. "ctrl+shift+v", "shift+insert"
+	PreDelay  string   `mapstructure:"pre_delay"`  // wait before injecting, e.g. "50ms"
+	PostDelay string   `mapstructure:"post_delay"` // wait after injecting
+}
+
+type HistoryConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	MaxEntries int    `mapstructure:"max_entries"` // 0 keeps everything
+	MaxAge     string `mapstructure:"max_age"`     // e.g. "720h"; empty keeps everything
 }
 
 // SaveHotkey rewrites only the hotkey.trigger field in the YAML config file.
 func SaveHotkey(cfg *Config, trigger string) error {
+	return editConfigFile(func(lines []string) ([]string, error) {
+		start, end, indent := sectionBlock(lines, "hotkey")
+		for i := start; i < end; i++ {
+			line := lines[i]
+			if lineIndent(line) == indent && strings.HasPrefix(strings.TrimSpace(line), "trigger:") {
+				lines[i] = indent + "trigger: \"" + trigger + "\""
+				return lines, nil
+			}
+		}
+		return nil, fmt.Errorf("trigger key not found in config file")
+	})
+}
+
+// SaveBindings replaces the hotkey.bindings list in the YAML config file,
+// leaving the rest of the file and its comments untouched.
+func SaveBindings(cfg *Config, bindings []BindingConfig) error {
+	return editConfigFile(func(lines []string) ([]string, error) {
+		start, end, indent := sectionBlock(lines, "hotkey")
+		if start < 0 {
+			return nil, fmt.Errorf("hotkey section not found in config file")
+		}
+		if indent == "" {
+			indent = "  "
+		}
+
+		// Drop the existing bindings key and everything nested under it
+		var kept []string
+		insertAt := -1
+		for i := start; i < end; i++ {
+			line := lines[i]
+			if lineIndent(line) == indent && strings.HasPrefix(strings.TrimSpace(line), "bindings:") {
+				insertAt = len(kept)
+				for i+1 < end && (strings.TrimSpace(lines[i+1]) == "" || len(lineIndent(lines[i+1])) > len(indent) ||
+					strings.HasPrefix(strings.TrimSpace(lines[i+1]), "-")) {
+					i++
+				}
+				continue
+			}
+			kept = append(kept, line)
+		}
+		if insertAt < 0 {
+			// Append after the last non-blank line of the section
+			insertAt = len(kept)
+			for insertAt > 0 && strings.TrimSpace(kept[insertAt-1]) == "" {
+				insertAt--
+			}
+		}
+
+		var block []string
+		if len(bindings) > 0 {
+			block = append(block, indent+"bindings:")
+			for _, b := range bindings {
+				block = append(block,
+					indent+"  - trigger: \""+b.Trigger+"\"",
+					indent+"    action: \""+b.Action+"\"")
+			}
+		}
+
+		section := append(append(append([]string{}, kept[:insertAt]...), block...), kept[insertAt:]...)
+		return append(append(append([]string{}, lines[:start]...), section...), lines[end:]...), nil
+	})
+}
+
+// SaveAudioDevice sets audio.device in the YAML config file, adding the key
+// if the file predates it.
+func SaveAudioDevice(cfg *Config, device string) error {
+	return editConfigFile(func(lines []string) ([]string, error) {
+		start, end, indent := sectionBlock(lines, "audio")
+		if start < 0 {
+			return nil, fmt.Errorf("audio section not found in config file")
+		}
+		if indent == "" {
+			indent = "  "
+		}
+		line := indent + "device: " + strconv.Quote(device)
+		for i := start; i < end; i++ {
+			if lineIndent(lines[i]) == indent && strings.HasPrefix(strings.TrimSpace(lines[i]), "device:") {
+				lines[i] = line
+				return lines, nil
+			}
+		}
+		return append(append(append([]string{}, lines[:start]...), line), lines[start:]...), nil
+	})
+}
+
+// editConfigFile applies edit to the lines of ~/.sussurro/config.yaml.
+func editConfigFile(edit func(lines []string) ([]string, error)) error {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf("cannot find home directory: %w", err)
@@ -70,23 +236,50 @@ func SaveHotkey(cfg *Config, trigger string) error {
 		return fmt.Errorf("cannot read config file: %w", err)
 	}
 
-	// Simple line-by-line replacement of the trigger value.
-	lines := strings.Split(string(data), "\n")
-	replaced := false
+	lines, err := edit(strings.Split(string(data), "\n"))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(configFile, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// sectionBlock finds the lines of the top-level section name: its keys are
+// lines[start:end], indented by indent. start is -1 if there is none.
+func sectionBlock(lines []string, name string) (start, end int, indent string) {
+	start = -1
 	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "trigger:") {
-			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
-			lines[i] = indent + "trigger: \"" + trigger + "\""
-			replaced = true
+		if strings.HasPrefix(line, name+":") {
+			start = i + 1
 			break
 		}
 	}
-	if !replaced {
-		return fmt.Errorf("trigger key not found in config file")
+	if start < 0 {
+		return -1, -1, ""
 	}
+	end = len(lines)
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if lineIndent(lines[i]) == "" {
+			end = i
+			break
+		}
+		if indent == "" {
+			indent = lineIndent(lines[i])
+		}
+	}
+	// Leave blank lines before the next section outside the block
+	for end > start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return start, end, indent
+}
 
-	return os.WriteFile(configFile, []byte(strings.Join(lines, "\n")), 0644)
+// lineIndent returns the leading whitespace of line.
+func lineIndent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
 }
 
 func LoadConfig(path string) (*Config, error) {
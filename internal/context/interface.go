@@ -1,15 +1,26 @@
 package context
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrWindowClosed is returned by Provider.Activate when the window no
+// longer exists.
+var ErrWindowClosed = errors.New("target window was closed")
+
 // ContextInfo holds information about the current user context
 type ContextInfo struct {
 	AppName     string
 	WindowTitle string
 	Timestamp   time.Time
+
+	// WindowID identifies the window for Activate: the X11 window on Linux
+	// (0 under Wayland), the CGWindowID on macOS.
+	WindowID uint64
+	// PID is the process owning the window (macOS only).
+	PID int
 }
 
 // String returns a formatted string of the context
@@ -20,5 +31,9 @@ func (c ContextInfo) String() string {
 // Provider defines the interface for context detection
 type Provider interface {
 	GetContext() (*ContextInfo, error)
+	// Activate gives focus back to the window described by info. It
+	// returns ErrWindowClosed if that window is gone, and does nothing
+	// when info carries no window to activate.
+	Activate(info *ContextInfo) error
 	Close() error
 }
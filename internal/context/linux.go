@@ -3,11 +3,17 @@
 package context
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// activateTimeout bounds how long Activate waits for the window manager.
+const activateTimeout = time.Second
+
 // LinuxProvider implements context detection for Linux systems using X11
 type LinuxProvider struct{}
 
@@ -31,6 +37,11 @@ func (p *LinuxProvider) GetContext() (*ContextInfo, error) {
 		info.WindowTitle = "unknown"
 		return info, nil
 	}
+	// Under Wayland xdotool only sees XWayland windows and may report one that
+	// no longer has focus, so the ID is not trusted for Activate there
+	if os.Getenv("WAYLAND_DISPLAY") == "" {
+		info.WindowID, _ = strconv.ParseUint(strings.TrimSpace(string(windowID)), 10, 64)
+	}
 
 	// Get window class (application name)
 	classCmd := exec.Command("xprop", "-id", strings.TrimSpace(string(windowID)), "WM_CLASS")
@@ -64,6 +75,35 @@ func (p *LinuxProvider) GetContext() (*ContextInfo, error) {
 	return info, nil
 }
 
+// Activate focuses the X11 window recorded in info, using xdotool. Contexts
+// captured under Wayland have no window ID and are left alone.
+func (p *LinuxProvider) Activate(info *ContextInfo) error {
+	if info == nil || info.WindowID == 0 {
+		return nil
+	}
+	id := strconv.FormatUint(info.WindowID, 10)
+
+	if active, err := exec.Command("xdotool", "getactivewindow").Output(); err == nil && strings.TrimSpace(string(active)) == id {
+		return nil
+	}
+	// Querying a destroyed window fails with BadWindow
+	if err := exec.Command("xdotool", "getwindowname", id).Run(); err != nil {
+		return ErrWindowClosed
+	}
+
+	cmd := exec.Command("xdotool", "windowactivate", "--sync", id)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("xdotool windowactivate: %w", err)
+	}
+	// --sync waits for the window manager, which may never comply
+	timer := time.AfterFunc(activateTimeout, func() { cmd.Process.Kill() })
+	defer timer.Stop()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("xdotool windowactivate: %w", err)
+	}
+	return nil
+}
+
 // Close cleans up any resources used by the provider
 func (p *LinuxProvider) Close() error {
 	// No resources to clean up for Linux implementation
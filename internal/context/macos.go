@@ -14,10 +14,12 @@ package context
 typedef struct {
     char* appName;
     char* windowTitle;
+    int pid;
+    unsigned int windowID;
 } WindowInfo;
 
 WindowInfo getActiveWindowInfo() {
-    WindowInfo info = {NULL, NULL};
+    WindowInfo info = {NULL, NULL, 0, 0};
 
     // Autorelease pool is required for Objective-C memory management
     @autoreleasepool {
@@ -34,6 +36,7 @@ WindowInfo getActiveWindowInfo() {
         }
 
         pid_t pid = [app processIdentifier];
+        info.pid = pid;
 
         // 2. Get a list of all visible windows on the screen
         // kCGWindowListOptionOnScreenOnly: Exclude off-screen windows
@@ -55,6 +58,7 @@ WindowInfo getActiveWindowInfo() {
                     // This filters out floating panels, status bars, etc.
                     NSNumber* layer = win[(__bridge NSString*)kCGWindowLayer];
                     if ([layer intValue] == 0) {
+                        info.windowID = [win[(__bridge NSString*)kCGWindowNumber] unsignedIntValue];
                         NSString* title = win[(__bridge NSString*)kCGWindowName];
                         if (title) {
                             info.windowTitle = strdup([title UTF8String]);
@@ -72,6 +76,40 @@ WindowInfo getActiveWindowInfo() {
     }
     return info;
 }
+
+// Activates the app owning a window. Returns 1 on success, 0 if the app or
+// the window is gone, and -1 if activation was refused.
+int activateWindow(int pid, unsigned int windowID) {
+    @autoreleasepool {
+        NSRunningApplication* app = [NSRunningApplication runningApplicationWithProcessIdentifier:pid];
+        if (app == nil || [app isTerminated]) {
+            return 0;
+        }
+
+        if (windowID != 0) {
+            CFArrayRef list = CGWindowListCopyWindowInfo(kCGWindowListOptionIncludingWindow, windowID);
+            BOOL exists = list != NULL && CFArrayGetCount(list) > 0;
+            if (list) {
+                CFRelease(list);
+            }
+            if (!exists) {
+                return 0;
+            }
+        }
+
+        if ([app isActive]) {
+            return 1;
+        }
+        return [app activateWithOptions:NSApplicationActivateIgnoringOtherApps] ? 1 : -1;
+    }
+}
+
+int frontmostPID() {
+    @autoreleasepool {
+        NSRunningApplication* app = [[NSWorkspace sharedWorkspace] frontmostApplication];
+        return app == nil ? 0 : [app processIdentifier];
+    }
+}
 */
 import "C"
 import (
@@ -80,6 +118,10 @@ import (
 	"unsafe"
 )
 
+// activateTimeout bounds how long Activate waits for the app to come to the
+// front.
+const activateTimeout = 500 * time.Millisecond
+
 // MacOSProvider implements the Provider interface for macOS
 type MacOSProvider struct{}
 
@@ -113,9 +155,36 @@ func (p *MacOSProvider) GetContext() (*ContextInfo, error) {
 		AppName:     appName,
 		WindowTitle: windowTitle,
 		Timestamp:   time.Now(),
+		WindowID:    uint64(info.windowID),
+		PID:         int(info.pid),
 	}, nil
 }
 
+// Activate brings the app owning the recorded window back to the front.
+// macOS activates apps rather than single windows, so the app's frontmost
+// window receives the text.
+func (p *MacOSProvider) Activate(info *ContextInfo) error {
+	if info == nil || info.PID == 0 {
+		return nil
+	}
+	switch C.activateWindow(C.int(info.PID), C.uint(info.WindowID)) {
+	case 0:
+		return ErrWindowClosed
+	case -1:
+		return fmt.Errorf("failed to activate %s", info.AppName)
+	}
+
+	// Activation is asynchronous; wait until the app is frontmost
+	deadline := time.Now().Add(activateTimeout)
+	for int(C.frontmostPID()) != info.PID {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s did not come to the front", info.AppName)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
 // Close releases any resources (none for this implementation)
 func (p *MacOSProvider) Close() error {
 	return nil
@@ -0,0 +1,16 @@
+package context
+
+// Linux terminal emulators by lowercased WM_CLASS class name, as reported
+// in ContextInfo.AppName. Injection and the LLM style hints share them.
+var (
+	// TerminalApps paste the clipboard with Ctrl+Shift+V.
+	TerminalApps = []string{
+		"konsole", "gnome-terminal", "gnome-terminal-server", "kgx", "org.gnome.console", "ptyxis",
+		"kitty", "alacritty", "org.wezfurlong.wezterm", "foot", "footclient", "ghostty", "com.mitchellh.ghostty",
+		"tilix", "terminator", "xfce4-terminal", "mate-terminal", "lxterminal", "qterminal", "terminology", "st-256color",
+	}
+
+	// XTermApps have no clipboard paste shortcut; Shift+Insert pastes the
+	// primary selection.
+	XTermApps = []string{"xterm", "urxvt", "rxvt"}
+)
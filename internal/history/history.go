@@ -0,0 +1,334 @@
+package history
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a single recorded dictation.
+type Entry struct {
+	ID       int64     `json:"id"`
+	Time     time.Time `json:"time"`
+	Raw      string    `json:"raw"`
+	Cleaned  string    `json:"cleaned"`
+	App      string    `json:"app,omitempty"`
+	Window   string    `json:"window,omitempty"`
+	ASRModel string    `json:"asr_model,omitempty"`
+	LLMModel string    `json:"llm_model,omitempty"`
+	Timings  Timings   `json:"timings"`
+}
+
+// Timings holds per-stage durations in milliseconds.
+type Timings struct {
+	AudioMS int64 `json:"audio_ms"`
+	ASRMS   int64 `json:"asr_ms"`
+	LLMMS   int64 `json:"llm_ms"`
+	TotalMS int64 `json:"total_ms"`
+}
+
+// Retention limits how much history is kept. Zero values mean "no limit".
+type Retention struct {
+	MaxEntries int
+	MaxAge     time.Duration
+}
+
+// Store is an append-only JSON Lines history file. It is safe for concurrent
+// use within a process.
+type Store struct {
+	path      string
+	retention Retention
+
+	mu     sync.Mutex
+	nextID int64
+	count  int
+	oldest time.Time // time of the first entry in the file, zero when empty
+}
+
+// DefaultPath returns the history file location inside the Sussurro data
+// directory (~/.sussurro/history.jsonl).
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".sussurro", "history.jsonl"), nil
+}
+
+// Open opens (or creates) the history file at path and applies the retention
+// policy to any existing entries.
+func Open(path string, retention Retention) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	s := &Store{path: path, retention: retention, nextID: 1}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.readLocked()
+	if err != nil {
+		return nil, err
+	}
+	if kept := s.applyRetention(entries); len(kept) != len(entries) {
+		if err := s.rewriteLocked(kept); err != nil {
+			return nil, err
+		}
+		entries = kept
+	}
+	s.count = len(entries)
+	if len(entries) > 0 {
+		s.nextID = entries[len(entries)-1].ID + 1
+		s.oldest = entries[0].Time
+	}
+
+	return s, nil
+}
+
+// Path returns the location of the history file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append assigns an ID to e, writes it to the history file, and enforces the
+// retention policy. The stored entry is returned.
+func (s *Store) Append(e Entry) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.ID = s.nextID
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return e, fmt.Errorf("failed to encode history entry: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return e, fmt.Errorf("failed to open history file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return e, fmt.Errorf("failed to write history entry: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return e, fmt.Errorf("failed to write history entry: %w", err)
+	}
+
+	s.nextID++
+	s.count++
+	if s.oldest.IsZero() {
+		s.oldest = e.Time
+	}
+
+	if s.needsPruneLocked() {
+		entries, err := s.readLocked()
+		if err != nil {
+			return e, err
+		}
+		kept := s.applyRetention(entries)
+		if err := s.rewriteLocked(kept); err != nil {
+			return e, err
+		}
+		s.count = len(kept)
+		s.oldest = time.Time{}
+		if len(kept) > 0 {
+			s.oldest = kept[0].Time
+		}
+	}
+
+	return e, nil
+}
+
+// needsPruneLocked reports whether the file holds an expired entry or has
+// grown noticeably past MaxEntries. The slack on the count keeps a busy
+// session from rewriting the file on every dictation. Caller must hold s.mu.
+func (s *Store) needsPruneLocked() bool {
+	if age := s.retention.MaxAge; age > 0 && !s.oldest.IsZero() && s.oldest.Before(time.Now().Add(-age)) {
+		return true
+	}
+	limit := s.retention.MaxEntries
+	return limit > 0 && s.count > limit+limit/10
+}
+
+// All returns every stored entry, oldest first.
+func (s *Store) All() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readLocked()
+}
+
+// Get returns the entry with the given ID.
+func (s *Store) Get(id int64) (Entry, error) {
+	entries, err := s.All()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("history entry %d not found", id)
+}
+
+// Search returns entries whose text, app, or window title contains query
+// (case-insensitive), oldest first.
+func (s *Store) Search(query string) ([]Entry, error) {
+	entries, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	q := strings.ToLower(query)
+	var matches []Entry
+	for _, e := range entries {
+		haystack := strings.ToLower(e.Raw + "\n" + e.Cleaned + "\n" + e.App + "\n" + e.Window)
+		if strings.Contains(haystack, q) {
+			matches = append(matches, e)
+		}
+	}
+	return matches, nil
+}
+
+// Clear removes all entries.
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.rewriteLocked(nil); err != nil {
+		return err
+	}
+	s.count = 0
+	s.oldest = time.Time{}
+	return nil
+}
+
+// readLocked loads all entries from disk. Malformed lines (e.g. a partial
+// write after a crash) are skipped. Caller must hold s.mu.
+func (s *Store) readLocked() ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return entries, nil
+}
+
+// rewriteLocked atomically replaces the history file with entries.
+// Caller must hold s.mu.
+func (s *Store) rewriteLocked(entries []Entry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".history-*.jsonl")
+	if err != nil {
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to rewrite history: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// applyRetention drops entries that are too old or exceed MaxEntries,
+// keeping the newest ones.
+func (s *Store) applyRetention(entries []Entry) []Entry {
+	if age := s.retention.MaxAge; age > 0 {
+		cutoff := time.Now().Add(-age)
+		i := 0
+		for i < len(entries) && entries[i].Time.Before(cutoff) {
+			i++
+		}
+		entries = entries[i:]
+	}
+	if limit := s.retention.MaxEntries; limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// WriteJSON writes entries as an indented JSON array.
+func WriteJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// WriteCSV writes entries as CSV with a header row.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	header := []string{"id", "time", "app", "window", "raw", "cleaned",
+		"asr_model", "llm_model", "audio_ms", "asr_ms", "llm_ms", "total_ms"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		record := []string{
+			strconv.FormatInt(e.ID, 10),
+			e.Time.Format(time.RFC3339),
+			e.App,
+			e.Window,
+			e.Raw,
+			e.Cleaned,
+			e.ASRModel,
+			e.LLMModel,
+			strconv.FormatInt(e.Timings.AudioMS, 10),
+			strconv.FormatInt(e.Timings.ASRMS, 10),
+			strconv.FormatInt(e.Timings.LLMMS, 10),
+			strconv.FormatInt(e.Timings.TotalMS, 10),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,25 @@
+package hotkey
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hotkey backends selectable with hotkey.backend.
+const (
+	BackendAuto  = "auto"  // X11 grab, Wayland portal, or macOS event tap
+	BackendEvdev = "evdev" // read keyboards from /dev/input (Linux)
+)
+
+// ParseBackend normalises a hotkey.backend value. The empty string means
+// auto.
+func ParseBackend(backend string) (string, error) {
+	switch b := strings.ToLower(strings.TrimSpace(backend)); b {
+	case "":
+		return BackendAuto, nil
+	case BackendAuto, BackendEvdev:
+		return b, nil
+	default:
+		return "", fmt.Errorf("unknown hotkey.backend %q (use auto or evdev)", backend)
+	}
+}
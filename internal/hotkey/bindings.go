@@ -0,0 +1,158 @@
+package hotkey
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cesp99/sussurro/internal/config"
+)
+
+// Actions a hotkey binding can run.
+const (
+	ActionDictate    = "dictate"     // record, then clean up with the LLM
+	ActionDictateRaw = "dictate_raw" // record and inject the raw transcript
+	ActionCancel     = "cancel"      // discard the current recording
+	ActionReinject   = "reinject"    // inject the last result again
+	ActionSettings   = "settings"    // open the settings window
+	ActionHandsFree  = "handsfree"   // turn hands-free dictation on or off
+)
+
+// actionLabels describe each action, in the order they are listed.
+var actionLabels = []struct{ action, label string }{
+	{ActionDictate, "Dictate"},
+	{ActionDictateRaw, "Dictate without cleanup"},
+	{ActionCancel, "Cancel recording"},
+	{ActionReinject, "Re-inject last result"},
+	{ActionSettings, "Open settings"},
+	{ActionHandsFree, "Toggle hands-free dictation"},
+}
+
+// ActionNames returns every action, in the order they are listed.
+func ActionNames() []string {
+	names := make([]string, 0, len(actionLabels))
+	for _, a := range actionLabels {
+		names = append(names, a.action)
+	}
+	return names
+}
+
+// ActionLabel returns a human-readable name for action.
+func ActionLabel(action string) string {
+	for _, a := range actionLabels {
+		if a.action == action {
+			return a.label
+		}
+	}
+	return action
+}
+
+// Binding maps a trigger such as "ctrl+shift+space" to an action.
+type Binding struct {
+	Trigger string `json:"trigger"`
+	Action  string `json:"action"`
+}
+
+// Callbacks run when a binding's trigger goes down and up. Abort runs
+// instead of Up when a modifier key bound on its own turns out to be part of
+// a shortcut; when it is nil, Up runs. Any of them may be nil.
+type Callbacks struct {
+	Down  func()
+	Up    func()
+	Abort func()
+}
+
+// Actions maps action names to their callbacks.
+type Actions map[string]Callbacks
+
+// keyDown runs the press callback of action, if any.
+func (a Actions) keyDown(action string) {
+	if cb := a[action].Down; cb != nil {
+		cb()
+	}
+}
+
+// keyUp runs the release callback of action, if any.
+func (a Actions) keyUp(action string) {
+	if cb := a[action].Up; cb != nil {
+		cb()
+	}
+}
+
+// keyAbort runs the abort callback of action, or its release callback.
+func (a Actions) keyAbort(action string) {
+	if cb := a[action].Abort; cb != nil {
+		cb()
+		return
+	}
+	a.keyUp(action)
+}
+
+// ParseBindings combines hotkey.trigger, which dictates, with the extra
+// bindings from hotkey.bindings. Triggers are normalised, and unknown
+// actions, invalid triggers, and chords bound twice are rejected.
+func ParseBindings(trigger string, extra []Binding) ([]Binding, error) {
+	var all []Binding
+	if strings.TrimSpace(trigger) != "" {
+		all = append(all, Binding{Trigger: trigger, Action: ActionDictate})
+	}
+	all = append(all, extra...)
+	return ValidateBindings(all)
+}
+
+// ConfigBindings returns the bindings in the hotkey section of cfg.
+func ConfigBindings(cfg config.HotkeyConfig) ([]Binding, error) {
+	extra := make([]Binding, 0, len(cfg.Bindings))
+	for _, b := range cfg.Bindings {
+		extra = append(extra, Binding{Trigger: b.Trigger, Action: b.Action})
+	}
+	return ParseBindings(cfg.Trigger, extra)
+}
+
+// ValidateBindings checks bindings and returns them with normalised
+// triggers and actions.
+func ValidateBindings(bindings []Binding) ([]Binding, error) {
+	out := make([]Binding, 0, len(bindings))
+	seen := make(map[string]string) // trigger -> action
+	for _, b := range bindings {
+		action := strings.ToLower(strings.TrimSpace(b.Action))
+		if ActionLabel(action) == action {
+			return nil, fmt.Errorf("unknown hotkey action %q (use dictate, dictate_raw, cancel, reinject, settings, or handsfree)", b.Action)
+		}
+		trigger, err := NormalizeTrigger(b.Trigger)
+		if err != nil {
+			return nil, fmt.Errorf("hotkey binding for %s: %w", action, err)
+		}
+		if other, dup := seen[trigger]; dup {
+			return nil, fmt.Errorf("%s is bound to both %s and %s", trigger, ActionLabel(other), ActionLabel(action))
+		}
+		seen[trigger] = action
+		out = append(out, Binding{Trigger: trigger, Action: action})
+	}
+	return out, nil
+}
+
+// NormalizeTrigger returns trigger in canonical form: lower case, key and
+// modifier aliases resolved, and modifiers in the order ctrl, shift, alt,
+// super. "Shift+Control+Space" and "ctrl+shift+space" normalise to the same
+// string, so duplicates can be found by comparing normalised triggers.
+func NormalizeTrigger(trigger string) (string, error) {
+	c, err := parseChord(trigger)
+	if err != nil {
+		return "", err
+	}
+	return c.String(), nil
+}
+
+// SplitBindings separates the bindings that need raw key events, which
+// only the evdev backend sees: modifier-only and double-tap triggers. Key
+// grabs and portal shortcuts take the others.
+func SplitBindings(bindings []Binding) (chords, raw []Binding) {
+	for _, b := range bindings {
+		if c, err := parseChord(b.Trigger); err == nil && c.raw() {
+			raw = append(raw, b)
+		} else {
+			chords = append(chords, b)
+		}
+	}
+	return chords, raw
+}
@@ -0,0 +1,116 @@
+// Package grab registers hotkeys through golang.design/x/hotkey: an X11
+// key grab on Linux and a Carbon hotkey on macOS. It is kept apart from
+// package hotkey because linking the library on Linux opens an X display
+// at init and panics without one, which would stop the evdev and portal
+// backends, and their tests, from running headless.
+package grab
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	ihk "github.com/cesp99/sussurro/internal/hotkey"
+	"golang.design/x/hotkey"
+)
+
+// Handler manages global hotkeys
+type Handler struct {
+	keys []boundHotkey
+	log  *slog.Logger
+	done chan struct{}
+
+	actions ihk.Actions
+}
+
+// boundHotkey is one registered binding.
+type boundHotkey struct {
+	hk     *hotkey.Hotkey
+	action string
+}
+
+// NewHandler creates a new hotkey handler for bindings
+func NewHandler(bindings []ihk.Binding, log *slog.Logger) (*Handler, error) {
+	// Check if we're on Wayland
+	if ihk.IsWayland() {
+		log.Error("Wayland detected: Global hotkeys are not supported")
+		log.Error("Solution 1: Log out and select an X11 session instead")
+		log.Error("Solution 2: Configure your desktop environment to bind Ctrl+Shift+Space to trigger recording")
+		return nil, fmt.Errorf("global hotkeys require X11 - Wayland does not support them")
+	}
+
+	h := &Handler{
+		log:  log,
+		done: make(chan struct{}),
+	}
+	for _, b := range bindings {
+		mods, key, err := ParseTrigger(b.Trigger)
+		if err != nil {
+			return nil, err
+		}
+		h.keys = append(h.keys, boundHotkey{hk: hotkey.New(mods, key), action: b.Action})
+	}
+	return h, nil
+}
+
+// Register registers the hotkeys and starts listening
+func (h *Handler) Register(actions ihk.Actions) error {
+	h.actions = actions
+
+	for i, k := range h.keys {
+		if err := k.hk.Register(); err != nil {
+			for _, prev := range h.keys[:i] {
+				prev.hk.Unregister()
+			}
+			return fmt.Errorf("failed to register hotkey for %s: %w", ihk.ActionLabel(k.action), err)
+		}
+	}
+
+	// Start a listening loop per hotkey
+	for _, k := range h.keys {
+		go h.listen(k)
+	}
+
+	return nil
+}
+
+// Unregister unregisters the hotkeys and stops the listeners
+func (h *Handler) Unregister() {
+	close(h.done)
+	for _, k := range h.keys {
+		k.hk.Unregister()
+	}
+}
+
+// repeatWindow is how long a release waits for the press that X11
+// autorepeat sends right after it while the key is still held.
+const repeatWindow = 40 * time.Millisecond
+
+func (h *Handler) listen(k boundHotkey) {
+	for {
+		select {
+		case <-h.done:
+			h.log.Debug("Hotkey listener stopping")
+			return
+		case <-k.hk.Keydown():
+			h.log.Debug("Hotkey pressed", "action", k.action)
+			if cb := h.actions[k.action].Down; cb != nil {
+				cb()
+			}
+		case <-k.hk.Keyup():
+			// Autorepeat turns a held key into release/press pairs; a
+			// press that follows at once means the key is still down
+			select {
+			case <-k.hk.Keydown():
+				continue
+			case <-time.After(repeatWindow):
+			case <-h.done:
+				return
+			}
+			h.log.Debug("Hotkey released", "action", k.action)
+			if cb := h.actions[k.action].Up; cb != nil {
+				cb()
+			}
+		}
+	}
+}
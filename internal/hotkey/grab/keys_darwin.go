@@ -0,0 +1,30 @@
+//go:build darwin
+
+package grab
+
+import (
+	ihk "github.com/cesp99/sussurro/internal/hotkey"
+	"golang.design/x/hotkey"
+)
+
+// macModifiers maps canonical modifiers to macOS hotkey modifiers.
+var macModifiers = map[string]hotkey.Modifier{
+	"ctrl":  hotkey.ModCtrl,
+	"shift": hotkey.ModShift,
+	"alt":   hotkey.ModOption,
+	"super": hotkey.ModCmd,
+}
+
+// ParseTrigger parses a string like "ctrl+shift+space" into modifiers and
+// the macOS virtual key code to register.
+func ParseTrigger(trigger string) ([]hotkey.Modifier, hotkey.Key, error) {
+	keycode, names, err := ihk.MacKey(trigger)
+	if err != nil {
+		return nil, 0, err
+	}
+	var mods []hotkey.Modifier
+	for _, m := range names {
+		mods = append(mods, macModifiers[m])
+	}
+	return mods, hotkey.Key(keycode), nil
+}
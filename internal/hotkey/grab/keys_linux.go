@@ -0,0 +1,34 @@
+//go:build linux
+
+package grab
+
+import (
+	"fmt"
+
+	ihk "github.com/cesp99/sussurro/internal/hotkey"
+	"golang.design/x/hotkey"
+)
+
+// x11Modifiers are the X11 modifier masks golang.design/x/hotkey accepts.
+// Mod1 is Alt and Mod4 is Super on virtually all X11 systems.
+var x11Modifiers = []hotkey.Modifier{hotkey.ModCtrl, hotkey.ModShift, hotkey.Mod1, hotkey.Mod4}
+
+// ParseTrigger parses a string like "ctrl+shift+space" into modifiers and
+// the X11 keysym to grab.
+func ParseTrigger(trigger string) ([]hotkey.Modifier, hotkey.Key, error) {
+	keysym, mask, err := ihk.X11Key(trigger)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Keysyms above 16 bits, like the media keys, do not fit hotkey.Key
+	if keysym > 0xffff {
+		return nil, 0, fmt.Errorf("%s cannot be registered as an X11 hotkey", trigger)
+	}
+	var mods []hotkey.Modifier
+	for _, m := range x11Modifiers {
+		if mask&uint32(m) != 0 {
+			mods = append(mods, m)
+		}
+	}
+	return mods, hotkey.Key(keysym), nil
+}
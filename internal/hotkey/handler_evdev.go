@@ -0,0 +1,625 @@
+//go:build linux
+
+package hotkey
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Event types and codes from linux/input-event-codes.h.
+const (
+	evSyn       = 0x00
+	evKey       = 0x01
+	synDropped  = 3
+	keyMaxCode  = 0x2ff
+	evdevDir    = "/dev/input"
+	virtualName = "Sussurro virtual keyboard" // our own uinput device
+)
+
+// ioctls from linux/input.h.
+func eviocgname(size int) uintptr    { return ioc(2, 0x06, size) }
+func eviocgkey(size int) uintptr     { return ioc(2, 0x18, size) }
+func eviocgbit(ev, size int) uintptr { return ioc(2, 0x20+ev, size) }
+func ioc(dir, nr, size int) uintptr  { return uintptr(dir<<30 | size<<16 | 'E'<<8 | nr) }
+
+// evdevModifiers maps a trigger modifier to the left and right keys that
+// satisfy it.
+var evdevModifiers = map[string][2]uint16{
+	"ctrl":  {29, 97},   // KEY_LEFTCTRL, KEY_RIGHTCTRL
+	"shift": {42, 54},   // KEY_LEFTSHIFT, KEY_RIGHTSHIFT
+	"alt":   {56, 100},  // KEY_LEFTALT, KEY_RIGHTALT
+	"super": {125, 126}, // KEY_LEFTMETA, KEY_RIGHTMETA
+}
+
+// doubleTapWindow is how quickly the second tap of a double-tap trigger
+// must follow the first, and how short the first tap must be.
+const doubleTapWindow = 400 * time.Millisecond
+
+// evdevChord is a trigger translated to evdev key codes.
+type evdevChord struct {
+	key    uint16
+	mods   [][2]uint16 // each entry is satisfied by either of its keys
+	double bool        // fires on the second of two quick taps of key
+	modKey bool        // key is itself a modifier key
+}
+
+// evdevBinding is a binding translated to evdev key codes.
+type evdevBinding struct {
+	chord  evdevChord
+	action string
+}
+
+// EvdevHandler detects the triggers by reading keyboards directly from
+// /dev/input/event*. It works on any compositor, but needs read access to
+// the input devices (the "input" group) and does not stop the chord from
+// reaching the focused application.
+type EvdevHandler struct {
+	log    *slog.Logger
+	done   chan struct{}
+	events chan evdevEvent
+
+	actions Actions
+
+	mu       sync.Mutex
+	bindings []evdevBinding // which keys make a device worth reading
+	devices  map[string]*os.File
+	watch    *os.File
+	stopOnce sync.Once
+
+	// Owned by the event loop
+	current []evdevBinding
+	state   []evdevState               // per binding in current
+	pressed map[string]map[uint16]bool // per device
+}
+
+// evdevState tracks one binding in the event loop.
+type evdevState struct {
+	active   bool      // the binding's press has fired
+	tapStart time.Time // press of the first tap of a double tap, while held
+	tapEnd   time.Time // release of a completed first tap
+}
+
+// evdevEvent is a key event from one device, or a change to its state.
+type evdevEvent struct {
+	dev   string
+	code  uint16
+	value int32     // 0 release, 1 press, 2 autorepeat
+	at    time.Time // kernel timestamp of a key event
+
+	resync bool     // replace the device's pressed keys with keys
+	keys   []uint16 // pressed keys for resync
+	gone   bool     // the device was removed
+
+	rebind []evdevBinding // non-nil: switch to these bindings
+}
+
+// NewEvdevHandler creates a handler for bindings.
+func NewEvdevHandler(bindings []Binding, log *slog.Logger) (*EvdevHandler, error) {
+	evBindings, err := parseEvdevBindings(bindings)
+	if err != nil {
+		return nil, err
+	}
+	return &EvdevHandler{
+		log:      log,
+		done:     make(chan struct{}),
+		events:   make(chan evdevEvent, 64),
+		bindings: evBindings,
+		current:  evBindings,
+		state:    make([]evdevState, len(evBindings)),
+		devices:  make(map[string]*os.File),
+		pressed:  make(map[string]map[uint16]bool),
+	}, nil
+}
+
+// parseEvdevBindings converts bindings to evdev key codes.
+func parseEvdevBindings(bindings []Binding) ([]evdevBinding, error) {
+	out := make([]evdevBinding, 0, len(bindings))
+	for _, b := range bindings {
+		chord, err := parseEvdevTrigger(b.Trigger)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, evdevBinding{chord: chord, action: b.Action})
+	}
+	return out, nil
+}
+
+// parseEvdevTrigger converts a trigger string to evdev key codes.
+func parseEvdevTrigger(trigger string) (evdevChord, error) {
+	c, err := parseChord(trigger)
+	if err != nil {
+		return evdevChord{}, err
+	}
+	chord := evdevChord{key: c.def.evdev, double: c.double, modKey: c.modifierOnly()}
+	for _, m := range c.mods {
+		chord.mods = append(chord.mods, evdevModifiers[m])
+	}
+	return chord, nil
+}
+
+// Register opens every keyboard, starts watching for new ones, and starts
+// listening. It fails if no input device could be read.
+func (h *EvdevHandler) Register(actions Actions) error {
+	h.actions = actions
+
+	go h.listen()
+	if err := h.watchDevices(); err != nil {
+		h.log.Warn("evdev: keyboards plugged in later will not be seen", "error", err)
+	}
+	denied := h.scanDevices()
+
+	h.mu.Lock()
+	opened := len(h.devices)
+	h.mu.Unlock()
+	if opened == 0 && len(denied) > 0 {
+		h.Unregister()
+		return evdevPermissionError(denied[0])
+	}
+	if opened == 0 {
+		h.log.Warn("evdev: no keyboard found yet, waiting for one to be plugged in")
+	}
+
+	h.log.Info("Listening for the hotkey on input devices", "keyboards", opened)
+	return nil
+}
+
+// SetBindings switches to new bindings without reopening the devices.
+// Bindings held down at the time are released first.
+func (h *EvdevHandler) SetBindings(bindings []Binding) error {
+	evBindings, err := parseEvdevBindings(bindings)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.bindings = evBindings
+	h.mu.Unlock()
+	h.send(evdevEvent{rebind: evBindings})
+
+	// Keyboards without the old keys were skipped; they may have a new one
+	h.scanDevices()
+	return nil
+}
+
+// scanDevices opens every keyboard in /dev/input and returns the devices
+// that could not be opened for lack of permission.
+func (h *EvdevHandler) scanDevices() (denied []string) {
+	paths, _ := filepath.Glob(filepath.Join(evdevDir, "event*"))
+	for _, p := range paths {
+		if err := h.openDevice(p); errors.Is(err, os.ErrPermission) {
+			denied = append(denied, p)
+		}
+	}
+	return denied
+}
+
+// Unregister closes the devices and stops the listener.
+func (h *EvdevHandler) Unregister() {
+	h.stopOnce.Do(func() {
+		close(h.done)
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.watch != nil {
+			h.watch.Close()
+		}
+		for _, f := range h.devices {
+			f.Close()
+		}
+		h.devices = map[string]*os.File{}
+	})
+}
+
+// openDevice starts reading path if it is a keyboard with a bound key.
+// Devices that are already open, or are not keyboards, are skipped.
+func (h *EvdevHandler) openDevice(path string) error {
+	h.mu.Lock()
+	_, open := h.devices[path]
+	bindings := h.bindings
+	h.mu.Unlock()
+	if open {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return err
+	}
+	name := deviceName(f)
+	if name == virtualName || !hasAnyKey(f, bindings) {
+		f.Close()
+		return nil
+	}
+	keys, err := pressedKeys(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	h.mu.Lock()
+	select {
+	case <-h.done:
+		h.mu.Unlock()
+		f.Close()
+		return nil
+	default:
+	}
+	if _, open := h.devices[path]; open {
+		h.mu.Unlock()
+		f.Close()
+		return nil
+	}
+	h.devices[path] = f
+	h.mu.Unlock()
+
+	h.log.Debug("evdev: reading keyboard", "device", path, "name", name)
+	h.send(evdevEvent{dev: path, resync: true, keys: keys})
+	go h.readDevice(path, f)
+	return nil
+}
+
+// readDevice forwards key events from f until it is closed or removed.
+func (h *EvdevHandler) readDevice(path string, f *os.File) {
+	err := forwardEvents(path, f, func() ([]uint16, error) { return pressedKeys(f) }, h.send)
+
+	h.mu.Lock()
+	if h.devices[path] == f {
+		delete(h.devices, path)
+		f.Close()
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-h.done:
+	default:
+		h.log.Debug("evdev: keyboard removed", "device", path, "error", err)
+		h.send(evdevEvent{dev: path, gone: true})
+	}
+}
+
+// forwardEvents decodes the struct input_event records r reads from the
+// keyboard dev and passes them to send until r returns an error. After
+// SYN_DROPPED the events in between are lost, so keys reads the device's
+// state back instead.
+func forwardEvents(dev string, r io.Reader, keys func() ([]uint16, error), send func(evdevEvent)) error {
+	return decodeEvents(r, func(at time.Time, typ, code uint16, value int32) {
+		switch {
+		case typ == evKey:
+			send(evdevEvent{dev: dev, code: code, value: value, at: at})
+		case typ == evSyn && code == synDropped:
+			if keys, err := keys(); err == nil {
+				send(evdevEvent{dev: dev, resync: true, keys: keys})
+			}
+		}
+	})
+}
+
+// replay runs a recording of the keyboard dev through the event loop
+// synchronously, as readDevice would deliver it; listen must not be
+// running. keys stands in for reading the device state after SYN_DROPPED.
+// The recording may continue in a later call after io.EOF; any other read
+// error removes the device, as it does for a real keyboard.
+func (h *EvdevHandler) replay(dev string, r io.Reader, keys func() ([]uint16, error)) error {
+	err := forwardEvents(dev, r, keys, h.handle)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	h.handle(evdevEvent{dev: dev, gone: true})
+	return err
+}
+
+func (h *EvdevHandler) send(ev evdevEvent) {
+	select {
+	case h.events <- ev:
+	case <-h.done:
+	}
+}
+
+// watchDevices opens keyboards as they appear in /dev/input. udev changes
+// the permissions of a new node after creating it, so attribute changes are
+// watched too.
+func (h *EvdevHandler) watchDevices() error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("inotify: %w", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, evdevDir, syscall.IN_CREATE|syscall.IN_ATTRIB); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("inotify %s: %w", evdevDir, err)
+	}
+	h.mu.Lock()
+	h.watch = os.NewFile(uintptr(fd), "inotify")
+	h.mu.Unlock()
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := h.watch.Read(buf)
+			if err != nil {
+				return
+			}
+			for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+				ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+				nameStart := off + syscall.SizeofInotifyEvent
+				name := strings.TrimRight(string(buf[nameStart:nameStart+int(ev.Len)]), "\x00")
+				off = nameStart + int(ev.Len)
+				if strings.HasPrefix(name, "event") {
+					if err := h.openDevice(filepath.Join(evdevDir, name)); err != nil && !errors.Is(err, os.ErrPermission) {
+						h.log.Debug("evdev: cannot open new device", "device", name, "error", err)
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (h *EvdevHandler) listen() {
+	for {
+		select {
+		case <-h.done:
+			h.log.Debug("Hotkey listener stopping")
+			return
+		case ev := <-h.events:
+			h.handle(ev)
+		}
+	}
+}
+
+// handle updates the key state and fires the callbacks. A press only
+// starts a binding when exactly its modifiers are held, like an X11 grab;
+// releasing the key or any of its modifiers ends it. Keys held on different
+// keyboards count together, so Ctrl on one and Space on another still match.
+//
+// A modifier key bound on its own only starts when nothing else is held,
+// and pressing another key while it is held aborts it, since that makes it
+// part of an ordinary shortcut. A double-tap binding starts on the second
+// of two taps within doubleTapWindow, with no other key in between.
+func (h *EvdevHandler) handle(ev evdevEvent) {
+	switch {
+	case ev.rebind != nil:
+		for i, b := range h.current {
+			if h.state[i].active {
+				h.actions.keyUp(b.action)
+			}
+		}
+		h.current = ev.rebind
+		h.state = make([]evdevState, len(ev.rebind))
+		return
+	case ev.gone:
+		delete(h.pressed, ev.dev)
+	case ev.resync:
+		keys := make(map[uint16]bool, len(ev.keys))
+		for _, k := range ev.keys {
+			keys[k] = true
+		}
+		h.pressed[ev.dev] = keys
+	case ev.value == 2:
+		return // autorepeat
+	default:
+		keys := h.pressed[ev.dev]
+		if keys == nil {
+			keys = make(map[uint16]bool)
+			h.pressed[ev.dev] = keys
+		}
+		if ev.value == 1 {
+			keys[ev.code] = true
+		} else {
+			delete(keys, ev.code)
+		}
+	}
+
+	held := func(code uint16) bool {
+		for _, keys := range h.pressed {
+			if keys[code] {
+				return true
+			}
+		}
+		return false
+	}
+	pressed := !ev.gone && !ev.resync && ev.value == 1
+	released := !ev.gone && !ev.resync && ev.value == 0
+	now := ev.at
+
+	for i, b := range h.current {
+		st := &h.state[i]
+		modsHeld := true
+		for _, alts := range b.chord.mods {
+			if !held(alts[0]) && !held(alts[1]) {
+				modsHeld = false
+			}
+		}
+
+		if st.active {
+			switch {
+			case !held(b.chord.key) || !modsHeld:
+				st.active = false
+				h.log.Debug("Hotkey released", "action", b.action)
+				h.actions.keyUp(b.action)
+			case b.chord.modKey && pressed && !inChord(b.chord, ev.code):
+				st.active = false
+				h.log.Debug("Hotkey aborted by another key", "action", b.action)
+				h.actions.keyAbort(b.action)
+			}
+			continue
+		}
+
+		if b.chord.double {
+			switch {
+			case pressed && ev.code == b.chord.key && h.onlyHeld(b.chord):
+				if !st.tapEnd.IsZero() && now.Sub(st.tapEnd) <= doubleTapWindow {
+					*st = evdevState{active: true}
+					h.log.Debug("Hotkey pressed", "action", b.action)
+					h.actions.keyDown(b.action)
+				} else {
+					*st = evdevState{tapStart: now}
+				}
+			case released && ev.code == b.chord.key && !st.tapStart.IsZero():
+				// Only a short first press counts as a tap
+				if now.Sub(st.tapStart) <= doubleTapWindow {
+					*st = evdevState{tapEnd: now}
+				} else {
+					*st = evdevState{}
+				}
+			case pressed:
+				*st = evdevState{} // another key breaks the sequence
+			}
+			continue
+		}
+
+		if pressed && ev.code == b.chord.key && modsHeld && !extraModifiers(b.chord, held) &&
+			(!b.chord.modKey || h.onlyHeld(b.chord)) {
+			st.active = true
+			h.log.Debug("Hotkey pressed", "action", b.action)
+			h.actions.keyDown(b.action)
+		}
+	}
+}
+
+// onlyHeld reports whether every key held belongs to chord.
+func (h *EvdevHandler) onlyHeld(chord evdevChord) bool {
+	for _, keys := range h.pressed {
+		for code := range keys {
+			if !inChord(chord, code) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// inChord reports whether code is the chord's key or one of its modifiers.
+func inChord(chord evdevChord, code uint16) bool {
+	if code == chord.key {
+		return true
+	}
+	for _, alts := range chord.mods {
+		if code == alts[0] || code == alts[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// extraModifiers reports whether a modifier outside the chord is held. The
+// side of a modifier key that is itself the chord's key does not count.
+func extraModifiers(chord evdevChord, held func(uint16) bool) bool {
+	for _, codes := range evdevModifiers {
+		wanted := false
+		for _, alts := range chord.mods {
+			wanted = wanted || alts == codes
+		}
+		for _, code := range codes {
+			if !wanted && code != chord.key && held(code) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeEvents calls fn for every struct input_event read from r until r
+// returns an error.
+func decodeEvents(r io.Reader, fn func(at time.Time, typ, code uint16, value int32)) error {
+	tv := int(unsafe.Sizeof(syscall.Timeval{}))
+	size := tv + 8
+	buf := make([]byte, size*64)
+	for {
+		n, err := io.ReadAtLeast(r, buf, size)
+		if err == nil && n%size != 0 {
+			// Complete a partially read record
+			var m int
+			m, err = io.ReadFull(r, buf[n:n+size-n%size])
+			n += m
+		}
+		for off := 0; off+size <= n; off += size {
+			rec := buf[off+tv : off+size]
+			fn(decodeTimeval(buf[off:off+tv]), binary.NativeEndian.Uint16(rec[0:]),
+				binary.NativeEndian.Uint16(rec[2:]), int32(binary.NativeEndian.Uint32(rec[4:])))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// decodeTimeval decodes a struct timeval of 32- or 64-bit fields.
+func decodeTimeval(b []byte) time.Time {
+	if len(b) == 8 {
+		sec, usec := int32(binary.NativeEndian.Uint32(b)), int32(binary.NativeEndian.Uint32(b[4:]))
+		return time.Unix(int64(sec), int64(usec)*1000)
+	}
+	sec, usec := int64(binary.NativeEndian.Uint64(b)), int64(binary.NativeEndian.Uint64(b[8:]))
+	return time.Unix(sec, usec*1000)
+}
+
+// deviceName returns the device's name, or "" if it cannot be read.
+func deviceName(f *os.File) string {
+	var name [256]byte
+	if evdevIoctl(f, eviocgname(len(name)), unsafe.Pointer(&name[0])) != nil {
+		return ""
+	}
+	return strings.TrimRight(string(name[:]), "\x00")
+}
+
+// hasAnyKey reports whether the device can send the key of any binding.
+func hasAnyKey(f *os.File, bindings []evdevBinding) bool {
+	var bits [keyMaxCode/8 + 1]byte
+	if evdevIoctl(f, eviocgbit(evKey, len(bits)), unsafe.Pointer(&bits[0])) != nil {
+		return false
+	}
+	for _, b := range bindings {
+		if code := b.chord.key; bits[code/8]&(1<<(code%8)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pressedKeys returns the keys currently held on the device.
+func pressedKeys(f *os.File) ([]uint16, error) {
+	var bits [keyMaxCode/8 + 1]byte
+	if err := evdevIoctl(f, eviocgkey(len(bits)), unsafe.Pointer(&bits[0])); err != nil {
+		return nil, fmt.Errorf("failed to read key state: %w", err)
+	}
+	var keys []uint16
+	for code := 0; code <= keyMaxCode; code++ {
+		if bits[code/8]&(1<<(code%8)) != 0 {
+			keys = append(keys, uint16(code))
+		}
+	}
+	return keys, nil
+}
+
+func evdevIoctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// evdevPermissionError explains how to give the user read access to input
+// devices.
+func evdevPermissionError(path string) error {
+	return fmt.Errorf("permission denied opening %s. The evdev hotkey backend needs to read "+
+		"keyboards: run 'sudo usermod -aG input $USER' and log in again", path)
+}
@@ -0,0 +1,250 @@
+//go:build linux
+
+package hotkey
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"syscall"
+	"testing"
+	"testing/iotest"
+	"unsafe"
+)
+
+// Event and key codes from linux/input-event-codes.h.
+const (
+	synReport    = 0
+	keyLeftCtrl  = 29
+	keyA         = 30
+	keyLeftShift = 42
+	keySpace     = 57
+	keyRightCtrl = 97
+)
+
+// inputEvent is a struct input_event with its time in milliseconds.
+type inputEvent struct {
+	ms        int64
+	typ, code uint16
+	value     int32
+}
+
+// key returns the key event and the sync report that follows it.
+func key(ms int64, code uint16, value int32) []inputEvent {
+	return []inputEvent{{ms, evKey, code, value}, {ms, evSyn, synReport, 0}}
+}
+
+// encodeEvents builds the bytes a keyboard sends for events.
+func encodeEvents(events ...[]inputEvent) []byte {
+	tv := int(unsafe.Sizeof(syscall.Timeval{}))
+	var buf []byte
+	for _, ev := range slices.Concat(events...) {
+		rec := make([]byte, tv+8)
+		sec, usec := ev.ms/1000, ev.ms%1000*1000
+		if tv == 8 {
+			binary.NativeEndian.PutUint32(rec, uint32(sec))
+			binary.NativeEndian.PutUint32(rec[4:], uint32(usec))
+		} else {
+			binary.NativeEndian.PutUint64(rec, uint64(sec))
+			binary.NativeEndian.PutUint64(rec[8:], uint64(usec))
+		}
+		binary.NativeEndian.PutUint16(rec[tv:], ev.typ)
+		binary.NativeEndian.PutUint16(rec[tv+2:], ev.code)
+		binary.NativeEndian.PutUint32(rec[tv+4:], uint32(ev.value))
+		buf = append(buf, rec...)
+	}
+	return buf
+}
+
+// newTestEvdevHandler returns a handler for bindings whose callbacks append
+// "+action" on press, "-action" on release and "!action" on abort to calls.
+func newTestEvdevHandler(t *testing.T, calls *[]string, bindings ...Binding) *EvdevHandler {
+	t.Helper()
+	h, err := NewEvdevHandler(bindings, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.actions = Actions{}
+	for _, b := range bindings {
+		h.actions[b.Action] = Callbacks{
+			Down:  func() { *calls = append(*calls, "+"+b.Action) },
+			Up:    func() { *calls = append(*calls, "-"+b.Action) },
+			Abort: func() { *calls = append(*calls, "!"+b.Action) },
+		}
+	}
+	return h
+}
+
+// noState fails the test if the handler asks for a device's state.
+func noState(t *testing.T) func() ([]uint16, error) {
+	return func() ([]uint16, error) {
+		t.Error("unexpected key state read")
+		return nil, nil
+	}
+}
+
+// replayAll replays data from dev and fails the test on a read error.
+func replayAll(t *testing.T, h *EvdevHandler, dev string, data []byte) {
+	t.Helper()
+	if err := h.replay(dev, bytes.NewReader(data), noState(t)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvdevChord(t *testing.T) {
+	var calls []string
+	h := newTestEvdevHandler(t, &calls,
+		Binding{Trigger: "ctrl+shift+space", Action: ActionDictate},
+		Binding{Trigger: "ctrl+space", Action: ActionCancel},
+	)
+
+	replayAll(t, h, "/dev/input/event3", encodeEvents(
+		key(0, keyLeftCtrl, 1),
+		key(10, keyLeftShift, 1),
+		key(20, keySpace, 1),
+		key(520, keySpace, 2),
+		key(550, keySpace, 2),
+		key(600, keySpace, 0),
+		key(610, keyLeftShift, 0),
+		// Shift released: Ctrl+Space is its own binding
+		key(700, keySpace, 1),
+		key(710, keyLeftCtrl, 0),
+		key(720, keySpace, 0),
+	))
+
+	want := []string{"+dictate", "-dictate", "+cancel", "-cancel"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+}
+
+func TestEvdevChordAcrossKeyboards(t *testing.T) {
+	var calls []string
+	h := newTestEvdevHandler(t, &calls, Binding{Trigger: "ctrl+space", Action: ActionDictate})
+
+	replayAll(t, h, "/dev/input/event3", encodeEvents(key(0, keyRightCtrl, 1)))
+	replayAll(t, h, "/dev/input/event7", encodeEvents(key(20, keySpace, 1)))
+	if want := []string{"+dictate"}; !slices.Equal(calls, want) {
+		t.Fatalf("after the press: got %v, want %v", calls, want)
+	}
+	replayAll(t, h, "/dev/input/event3", encodeEvents(key(300, keyRightCtrl, 0)))
+	replayAll(t, h, "/dev/input/event7", encodeEvents(key(310, keySpace, 0)))
+
+	want := []string{"+dictate", "-dictate"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+}
+
+func TestEvdevModifierOnlyAbort(t *testing.T) {
+	var calls []string
+	h := newTestEvdevHandler(t, &calls, Binding{Trigger: "rctrl", Action: ActionDictate})
+
+	replayAll(t, h, "/dev/input/event3", encodeEvents(
+		key(0, keyRightCtrl, 1),
+		key(100, keyA, 1), // Right Ctrl+A is a shortcut, not dictation
+		key(150, keyA, 0),
+		key(200, keyRightCtrl, 0),
+		key(500, keyRightCtrl, 1),
+		key(900, keyRightCtrl, 0),
+		// Held before Right Ctrl, A makes it part of a shortcut from the start
+		key(1000, keyA, 1),
+		key(1100, keyRightCtrl, 1),
+		key(1200, keyRightCtrl, 0),
+		key(1300, keyA, 0),
+	))
+
+	want := []string{"+dictate", "!dictate", "+dictate", "-dictate"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+}
+
+func TestEvdevDoubleTap(t *testing.T) {
+	var calls []string
+	h := newTestEvdevHandler(t, &calls, Binding{Trigger: "double+rctrl", Action: ActionDictate})
+
+	replayAll(t, h, "/dev/input/event3", encodeEvents(
+		// Second tap too late: it becomes the first tap
+		key(0, keyRightCtrl, 1),
+		key(50, keyRightCtrl, 0),
+		key(600, keyRightCtrl, 1),
+		key(650, keyRightCtrl, 0),
+		// Another key in between breaks the sequence
+		key(700, keyA, 1),
+		key(720, keyA, 0),
+		key(800, keyRightCtrl, 1),
+		// First press held too long to be a tap
+		key(1300, keyRightCtrl, 0),
+		key(1400, keyRightCtrl, 1),
+		key(1450, keyRightCtrl, 0),
+		// Within the window: held from the second press
+		key(1700, keyRightCtrl, 1),
+		key(2500, keyRightCtrl, 0),
+	))
+
+	want := []string{"+dictate", "-dictate"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+}
+
+func TestEvdevDroppedEvents(t *testing.T) {
+	var calls []string
+	h := newTestEvdevHandler(t, &calls, Binding{Trigger: "ctrl+space", Action: ActionDictate})
+
+	// The kernel dropped the releases of both keys and the press of A
+	reads := 0
+	state := func() ([]uint16, error) {
+		reads++
+		return []uint16{keyA}, nil
+	}
+	data := encodeEvents(
+		key(0, keyLeftCtrl, 1),
+		key(20, keySpace, 1),
+		[]inputEvent{{900, evSyn, synDropped, 0}},
+		key(950, keyA, 0),
+		key(1000, keyLeftCtrl, 1),
+		key(1020, keySpace, 1),
+	)
+	if err := h.replay("/dev/input/event3", bytes.NewReader(data), state); err != nil {
+		t.Fatal(err)
+	}
+
+	if reads != 1 {
+		t.Errorf("key state read %d times, want 1", reads)
+	}
+	want := []string{"+dictate", "-dictate", "+dictate"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+}
+
+func TestEvdevKeyboardRemovedWhileHeld(t *testing.T) {
+	var calls []string
+	h := newTestEvdevHandler(t, &calls, Binding{Trigger: "ctrl+space", Action: ActionDictate})
+
+	replayAll(t, h, "/dev/input/event7", encodeEvents(key(0, keyRightCtrl, 1)))
+	r := io.MultiReader(
+		bytes.NewReader(encodeEvents(key(0, keyLeftCtrl, 1), key(20, keySpace, 1))),
+		iotest.ErrReader(syscall.ENODEV),
+	)
+	if err := h.replay("/dev/input/event3", r, noState(t)); !errors.Is(err, syscall.ENODEV) {
+		t.Fatalf("got error %v, want ENODEV", err)
+	}
+
+	want := []string{"+dictate", "-dictate"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+
+	// Ctrl is still held on the other keyboard, but Space went with the
+	// removed one
+	replayAll(t, h, "/dev/input/event7", encodeEvents(key(500, keyRightCtrl, 0)))
+	if !slices.Equal(calls, want) {
+		t.Errorf("after the other keyboard's release: got %v, want %v", calls, want)
+	}
+}
@@ -3,78 +3,532 @@
 package hotkey
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/godbus/dbus/v5"
 )
 
-// WaylandHandler manages global hotkeys on Wayland via Desktop Portal
+// XDG Desktop Portal names used by the GlobalShortcuts flow.
+const (
+	portalBusName   = "org.freedesktop.portal.Desktop"
+	portalPath      = "/org/freedesktop/portal/desktop"
+	shortcutsIface  = "org.freedesktop.portal.GlobalShortcuts"
+	requestIface    = "org.freedesktop.portal.Request"
+	sessionIface    = "org.freedesktop.portal.Session"
+	pushToTalkID    = "push-to-talk"
+	pushToTalkLabel = "Sussurro push-to-talk"
+)
+
+// errPortalClosed is returned by pending portal requests when the handler
+// is unregistered.
+var errPortalClosed = errors.New("portal session closed")
+
+// WaylandHandler manages global hotkeys on Wayland via the GlobalShortcuts
+// Desktop Portal. The desktop (KDE Plasma, GNOME 48+, Hyprland, ...) owns
+// the actual key grab and reports presses as Activated/Deactivated signals.
 type WaylandHandler struct {
-	conn      *dbus.Conn
-	log       *slog.Logger
-	done      chan struct{}
-	sessionID string
-	shortcutID string
+	conn    *dbus.Conn
+	log     *slog.Logger
+	done    chan struct{}
+	signals chan *dbus.Signal
+
+	actions Actions
 
-	onKeyDown func()
-	onKeyUp   func()
+	mu          sync.Mutex
+	bindings    []portalBinding
+	sessionPath dbus.ObjectPath
+	pressed     map[string]bool // by shortcut ID
+	pending     map[dbus.ObjectPath]chan portalResponse
+	tokens      int
+	stopOnce    sync.Once
+}
+
+// portalBinding is a binding registered as a portal shortcut.
+type portalBinding struct {
+	id string
+	Binding
+}
 
-	mu sync.Mutex
-	pressed bool
+// portalResponse is the payload of a Request.Response signal.
+type portalResponse struct {
+	code    uint32 // 0 success, 1 cancelled by the user, 2 other error
+	results map[string]dbus.Variant
 }
 
-// NewWaylandHandler creates a new Wayland hotkey handler
-func NewWaylandHandler(trigger string, log *slog.Logger) (*WaylandHandler, error) {
-	conn, err := dbus.SessionBus()
+// NewWaylandHandler creates a new Wayland hotkey handler on a private
+// session bus connection.
+func NewWaylandHandler(bindings []Binding, log *slog.Logger) (*WaylandHandler, error) {
+	conn, err := dbus.ConnectSessionBus()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
 	}
+	return newWaylandHandler(conn, bindings, log), nil
+}
 
+// newWaylandHandler uses conn, which the handler closes on Unregister. The
+// connection may belong to any bus that provides the portal.
+func newWaylandHandler(conn *dbus.Conn, bindings []Binding, log *slog.Logger) *WaylandHandler {
 	return &WaylandHandler{
-		conn: conn,
-		log:  log,
-		done: make(chan struct{}),
-	}, nil
+		conn:     conn,
+		log:      log,
+		bindings: portalBindings(bindings),
+		done:     make(chan struct{}),
+		signals:  make(chan *dbus.Signal, 16),
+		pressed:  make(map[string]bool),
+		pending:  make(map[dbus.ObjectPath]chan portalResponse),
+	}
 }
 
-// Register registers the hotkey using Desktop Portal
-func (h *WaylandHandler) Register(onKeyDown, onKeyUp func()) error {
-	h.onKeyDown = onKeyDown
-	h.onKeyUp = onKeyUp
+// portalBindings assigns each binding a stable shortcut ID. The first
+// dictate binding keeps the original push-to-talk ID so desktops remember
+// the key the user confirmed; the others are named after their action.
+func portalBindings(bindings []Binding) []portalBinding {
+	out := make([]portalBinding, 0, len(bindings))
+	used := make(map[string]int)
+	for _, b := range bindings {
+		id := b.Action
+		if b.Action == ActionDictate {
+			id = pushToTalkID
+		}
+		if used[id]++; used[id] > 1 {
+			id = fmt.Sprintf("%s-%d", id, used[id])
+		}
+		out = append(out, portalBinding{id: id, Binding: b})
+	}
+	return out
+}
 
-	// For now, log that we're on Wayland and suggest alternatives
-	h.log.Warn("Wayland detected: Global hotkeys have limited support")
-	h.log.Info("Alternative: Use your desktop environment's keyboard settings to bind a command")
-	h.log.Info("Alternative: The application will work if you switch to an X11 session")
+// Register creates a portal session and binds a shortcut per binding with
+// its trigger as the preferred key. The desktop may show a dialog for the
+// user to confirm or change the keys, so Register can block until they
+// answer; callers on the UI path should run it in a goroutine.
+func (h *WaylandHandler) Register(actions Actions) error {
+	h.actions = actions
 
-	// Try to use Desktop Portal if available
-	obj := h.conn.Object("org.freedesktop.portal.Desktop", "/org/freedesktop/portal/desktop")
+	obj := h.conn.Object(portalBusName, portalPath)
 
 	// Check if GlobalShortcuts portal is available
 	var version uint32
-	err := obj.Call("org.freedesktop.DBus.Properties.Get", 0,
-		"org.freedesktop.portal.GlobalShortcuts", "version").Store(&version)
+	err := obj.Call("org.freedesktop.DBus.Properties.Get", 0, shortcutsIface, "version").Store(&version)
+	if err != nil {
+		return fmt.Errorf("GlobalShortcuts portal not available: configure a desktop shortcut for \"sussurro ctl\" instead")
+	}
+	h.log.Debug("GlobalShortcuts portal available", "version", version)
+
+	h.mu.Lock()
+	_, err = portalShortcuts(h.bindings)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := h.watchSignals(); err != nil {
+		return err
+	}
+	go h.listen()
+
+	// 1. CreateSession
+	sessionToken := h.nextToken()
+	resp, err := h.request(func(handleToken string) *dbus.Call {
+		return obj.Call(shortcutsIface+".CreateSession", 0, map[string]dbus.Variant{
+			"handle_token":         dbus.MakeVariant(handleToken),
+			"session_handle_token": dbus.MakeVariant(sessionToken),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("GlobalShortcuts CreateSession: %w", err)
+	}
+	sessionPath, err := sessionHandle(resp.results)
+	if err != nil {
+		return err
+	}
+
+	// 2. BindShortcuts, with the bindings SetBindings may have changed
+	// while the session was being created
+	h.mu.Lock()
+	h.sessionPath = sessionPath
+	bindings := h.bindings
+	h.mu.Unlock()
+	return h.bind(sessionPath, bindings)
+}
 
+// SetBindings binds new shortcuts in the registered session. Keys held at
+// the time are released first. Like Register it can block while the
+// desktop asks the user to confirm. Before Register has created the
+// session, the new bindings are simply the ones it binds.
+func (h *WaylandHandler) SetBindings(bindings []Binding) error {
+	pbs := portalBindings(bindings)
+	if _, err := portalShortcuts(pbs); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	old := h.bindings
+	h.mu.Unlock()
+	for _, b := range old {
+		h.keyUp(b)
+	}
+
+	h.mu.Lock()
+	h.bindings = pbs
+	session := h.sessionPath
+	h.mu.Unlock()
+	if session == "" || len(pbs) == 0 {
+		return nil
+	}
+	return h.bind(session, pbs)
+}
+
+// bind asks the portal to bind a shortcut per binding in session.
+func (h *WaylandHandler) bind(session dbus.ObjectPath, bindings []portalBinding) error {
+	shortcuts, err := portalShortcuts(bindings)
 	if err != nil {
-		h.log.Warn("GlobalShortcuts portal not available on this system")
-		h.log.Info("You can still use the app by configuring your DE to trigger it")
-		return fmt.Errorf("global shortcuts not available on Wayland: use X11 or configure DE shortcuts")
+		return err
+	}
+	obj := h.conn.Object(portalBusName, portalPath)
+	resp, err := h.request(func(handleToken string) *dbus.Call {
+		return obj.Call(shortcutsIface+".BindShortcuts", 0, session, shortcuts, "", map[string]dbus.Variant{
+			"handle_token": dbus.MakeVariant(handleToken),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("GlobalShortcuts BindShortcuts: %w", err)
 	}
 
-	h.log.Info("GlobalShortcuts portal available", "version", version)
-	// TODO: Implement full portal integration
-	return fmt.Errorf("GlobalShortcuts portal integration coming soon - please use X11 for now")
+	bound := h.boundTriggers(resp.results)
+	if len(bound) == 0 {
+		return fmt.Errorf("the desktop did not bind any shortcut")
+	}
+	for _, b := range bindings {
+		if trigger, ok := bound[b.id]; ok {
+			h.log.Info("Global shortcut bound via portal", "action", b.Action, "preferred", shortcutTrigger(shortcuts, b.id), "trigger", trigger)
+		} else {
+			h.log.Warn("The desktop did not bind a shortcut", "action", b.Action)
+		}
+	}
+	return nil
 }
 
-// Unregister unregisters the hotkey
+// portalShortcuts describes bindings as BindShortcuts arguments.
+func portalShortcuts(bindings []portalBinding) ([]portalShortcut, error) {
+	shortcuts := make([]portalShortcut, 0, len(bindings))
+	for _, b := range bindings {
+		preferred, err := portalTrigger(b.Trigger)
+		if err != nil {
+			return nil, err
+		}
+		label := pushToTalkLabel
+		if b.Action != ActionDictate {
+			label = "Sussurro: " + ActionLabel(b.Action)
+		}
+		shortcuts = append(shortcuts, portalShortcut{
+			ID: b.id,
+			Options: map[string]dbus.Variant{
+				"description":       dbus.MakeVariant(label),
+				"preferred_trigger": dbus.MakeVariant(preferred),
+			},
+		})
+	}
+	return shortcuts, nil
+}
+
+// Unregister closes the portal session and the bus connection.
 func (h *WaylandHandler) Unregister() {
-	close(h.done)
-	if h.conn != nil {
+	h.stopOnce.Do(func() {
+		h.mu.Lock()
+		session := h.sessionPath
+		h.mu.Unlock()
+		if session != "" {
+			h.conn.Object(portalBusName, session).Call(sessionIface+".Close", 0)
+		}
+		close(h.done)
+		h.conn.RemoveSignal(h.signals)
 		h.conn.Close()
+	})
+}
+
+// portalShortcut is the (sa{sv}) struct used by BindShortcuts and the
+// ShortcutsChanged signal.
+type portalShortcut struct {
+	ID      string
+	Options map[string]dbus.Variant
+}
+
+// watchSignals subscribes to request responses and shortcut activity.
+func (h *WaylandHandler) watchSignals() error {
+	matches := [][]dbus.MatchOption{
+		{dbus.WithMatchInterface(requestIface), dbus.WithMatchMember("Response")},
+		{dbus.WithMatchInterface(shortcutsIface), dbus.WithMatchObjectPath(portalPath)},
+		{dbus.WithMatchInterface(sessionIface), dbus.WithMatchMember("Closed")},
+	}
+	for _, m := range matches {
+		if err := h.conn.AddMatchSignal(m...); err != nil {
+			return fmt.Errorf("failed to watch portal signals: %w", err)
+		}
+	}
+	h.conn.Signal(h.signals)
+	return nil
+}
+
+// listen dispatches portal signals until Unregister.
+func (h *WaylandHandler) listen() {
+	for {
+		select {
+		case <-h.done:
+			h.log.Debug("Portal shortcut listener stopping")
+			return
+		case sig, ok := <-h.signals:
+			if !ok {
+				return
+			}
+			h.dispatch(sig)
+		}
+	}
+}
+
+func (h *WaylandHandler) dispatch(sig *dbus.Signal) {
+	switch sig.Name {
+	case requestIface + ".Response":
+		var resp portalResponse
+		if err := dbus.Store(sig.Body, &resp.code, &resp.results); err != nil {
+			h.log.Debug("Malformed portal response", "error", err)
+			return
+		}
+		h.mu.Lock()
+		ch := h.pending[sig.Path]
+		delete(h.pending, sig.Path)
+		h.mu.Unlock()
+		if ch != nil {
+			ch <- resp
+		}
+
+	case shortcutsIface + ".Activated", shortcutsIface + ".Deactivated":
+		var session dbus.ObjectPath
+		var id string
+		if len(sig.Body) < 2 || dbus.Store(sig.Body[:2], &session, &id) != nil {
+			return
+		}
+		b, ok := h.binding(session, id)
+		if !ok {
+			return
+		}
+		if strings.HasSuffix(sig.Name, ".Activated") {
+			h.keyDown(b)
+		} else {
+			h.keyUp(b)
+		}
+
+	case shortcutsIface + ".ShortcutsChanged":
+		var session dbus.ObjectPath
+		var shortcuts []portalShortcut
+		if dbus.Store(sig.Body, &session, &shortcuts) != nil || !h.ourSession(session) {
+			return
+		}
+		bound := h.boundTriggers(map[string]dbus.Variant{"shortcuts": dbus.MakeVariant(shortcuts)})
+		for _, b := range h.currentBindings() {
+			if trigger, ok := bound[b.id]; ok {
+				h.log.Info("Global shortcut changed in desktop settings", "action", b.Action, "trigger", trigger)
+			}
+		}
+
+	case sessionIface + ".Closed":
+		if h.ourSession(sig.Path) {
+			h.log.Warn("The desktop closed the global shortcut session; the shortcuts no longer work")
+			// Release keys held when the session went away
+			for _, b := range h.currentBindings() {
+				h.keyUp(b)
+			}
+		}
+	}
+}
+
+// ourSession reports whether a signal belongs to this handler's session.
+func (h *WaylandHandler) ourSession(session dbus.ObjectPath) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return session == h.sessionPath
+}
+
+// currentBindings returns the bindings in use.
+func (h *WaylandHandler) currentBindings() []portalBinding {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.bindings
+}
+
+// binding returns the binding a shortcut signal refers to.
+func (h *WaylandHandler) binding(session dbus.ObjectPath, id string) (portalBinding, bool) {
+	if !h.ourSession(session) {
+		return portalBinding{}, false
+	}
+	for _, b := range h.currentBindings() {
+		if b.id == id {
+			return b, true
+		}
+	}
+	return portalBinding{}, false
+}
+
+// keyDown fires the press callback once per press; desktops may repeat
+// Activated.
+func (h *WaylandHandler) keyDown(b portalBinding) {
+	h.mu.Lock()
+	if h.pressed[b.id] {
+		h.mu.Unlock()
+		return
+	}
+	h.pressed[b.id] = true
+	h.mu.Unlock()
+
+	h.log.Debug("Hotkey pressed", "action", b.Action)
+	h.actions.keyDown(b.Action)
+}
+
+func (h *WaylandHandler) keyUp(b portalBinding) {
+	h.mu.Lock()
+	if !h.pressed[b.id] {
+		h.mu.Unlock()
+		return
+	}
+	h.pressed[b.id] = false
+	h.mu.Unlock()
+
+	h.log.Debug("Hotkey released", "action", b.Action)
+	h.actions.keyUp(b.Action)
+}
+
+// request performs a portal method call that answers through a Request
+// object and waits for its Response signal. call receives the handle_token
+// to pass in the options.
+func (h *WaylandHandler) request(call func(handleToken string) *dbus.Call) (portalResponse, error) {
+	token := h.nextToken()
+	ch := make(chan portalResponse, 1)
+
+	// Register the predicted request path before calling, so a fast
+	// response cannot be missed.
+	expected := h.requestPath(token)
+	h.mu.Lock()
+	h.pending[expected] = ch
+	h.mu.Unlock()
+
+	var handle dbus.ObjectPath
+	if err := call(token).Store(&handle); err != nil {
+		h.mu.Lock()
+		delete(h.pending, expected)
+		h.mu.Unlock()
+		return portalResponse{}, err
+	}
+	if handle != expected {
+		// Portals before version 0.9 pick their own request path
+		h.mu.Lock()
+		delete(h.pending, expected)
+		h.pending[handle] = ch
+		h.mu.Unlock()
+	}
+
+	select {
+	case resp := <-ch:
+		switch resp.code {
+		case 0:
+			return resp, nil
+		case 1:
+			return resp, errors.New("cancelled by the user")
+		default:
+			return resp, fmt.Errorf("portal request failed (response %d)", resp.code)
+		}
+	case <-h.done:
+		return portalResponse{}, errPortalClosed
+	}
+}
+
+// requestPath returns the object path the portal uses for a request made
+// with token.
+func (h *WaylandHandler) requestPath(token string) dbus.ObjectPath {
+	sender := ""
+	if names := h.conn.Names(); len(names) > 0 {
+		sender = strings.ReplaceAll(strings.TrimPrefix(names[0], ":"), ".", "_")
+	}
+	return dbus.ObjectPath(portalPath + "/request/" + sender + "/" + token)
+}
+
+func (h *WaylandHandler) nextToken() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tokens++
+	return fmt.Sprintf("sussurro%d_%d", os.Getpid(), h.tokens)
+}
+
+// sessionHandle extracts the session path from a CreateSession response.
+// The spec declares it a string; some portals send an object path.
+func sessionHandle(results map[string]dbus.Variant) (dbus.ObjectPath, error) {
+	switch v := results["session_handle"].Value().(type) {
+	case string:
+		return dbus.ObjectPath(v), nil
+	case dbus.ObjectPath:
+		return v, nil
+	default:
+		return "", fmt.Errorf("GlobalShortcuts CreateSession returned no session handle")
+	}
+}
+
+// boundTriggers reads the shortcuts in a BindShortcuts or ShortcutsChanged
+// result and returns the desktop's description of each trigger by ID.
+func (h *WaylandHandler) boundTriggers(results map[string]dbus.Variant) map[string]string {
+	var shortcuts []portalShortcut
+	if v, ok := results["shortcuts"]; !ok || v.Store(&shortcuts) != nil {
+		return nil
+	}
+	bound := make(map[string]string, len(shortcuts))
+	for _, s := range shortcuts {
+		desc, _ := s.Options["trigger_description"].Value().(string)
+		if desc == "" {
+			desc = "(not assigned)"
+		}
+		bound[s.ID] = desc
+	}
+	return bound
+}
+
+// shortcutTrigger returns the preferred trigger requested for id.
+func shortcutTrigger(shortcuts []portalShortcut, id string) string {
+	for _, s := range shortcuts {
+		if s.ID == id {
+			desc, _ := s.Options["preferred_trigger"].Value().(string)
+			return desc
+		}
+	}
+	return ""
+}
+
+// portalModifiers translates trigger modifiers to the XDG shortcut format
+// ("CTRL+SHIFT+space"): upper-case modifiers and XKB keysym names.
+var portalModifiers = map[string]string{
+	"ctrl":  "CTRL",
+	"shift": "SHIFT",
+	"alt":   "ALT",
+	"super": "LOGO",
+}
+
+// portalTrigger converts a trigger like "ctrl+shift+space" into the
+// portal's preferred_trigger format.
+func portalTrigger(trigger string) (string, error) {
+	c, err := parseChord(trigger)
+	if err != nil {
+		return "", err
+	}
+	if c.raw() {
+		return "", errRawChord(c)
+	}
+	out := make([]string, 0, len(c.mods)+1)
+	for _, m := range c.mods {
+		out = append(out, portalModifiers[m])
 	}
+	return strings.Join(append(out, c.def.xkb), "+"), nil
 }
 
 // IsWayland checks if we're running on Wayland
@@ -0,0 +1,310 @@
+//go:build linux
+
+package hotkey
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// privateBus starts a dbus-daemon for the test and returns its address.
+func privateBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+	dir := t.TempDir()
+	config := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(config, []byte(strings.Replace(busConfig, "%s", filepath.Join(dir, "bus"), 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	addr, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("dbus-daemon did not print its address: %v", err)
+	}
+	return strings.TrimSpace(addr)
+}
+
+// fakePortal implements the parts of org.freedesktop.portal.GlobalShortcuts
+// the handler uses. Responses are emitted before the method returns, so the
+// handler only sees them if it predicted the request path.
+type fakePortal struct {
+	conn *dbus.Conn
+
+	mu        sync.Mutex
+	session   dbus.ObjectPath
+	bound     dbus.ObjectPath // session of the last BindShortcuts
+	shortcuts []portalShortcut
+}
+
+func (p *fakePortal) requestPath(sender dbus.Sender, options map[string]dbus.Variant) dbus.ObjectPath {
+	token, _ := options["handle_token"].Value().(string)
+	name := strings.ReplaceAll(strings.TrimPrefix(string(sender), ":"), ".", "_")
+	return dbus.ObjectPath(portalPath + "/request/" + name + "/" + token)
+}
+
+func (p *fakePortal) respond(path dbus.ObjectPath, results map[string]dbus.Variant) *dbus.Error {
+	if err := p.conn.Emit(path, requestIface+".Response", uint32(0), results); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (p *fakePortal) CreateSession(sender dbus.Sender, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	token, _ := options["session_handle_token"].Value().(string)
+	name := strings.ReplaceAll(strings.TrimPrefix(string(sender), ":"), ".", "_")
+	session := portalPath + "/session/" + name + "/" + token
+
+	p.mu.Lock()
+	p.session = dbus.ObjectPath(session)
+	p.mu.Unlock()
+
+	path := p.requestPath(sender, options)
+	return path, p.respond(path, map[string]dbus.Variant{"session_handle": dbus.MakeVariant(session)})
+}
+
+func (p *fakePortal) BindShortcuts(sender dbus.Sender, session dbus.ObjectPath, shortcuts []portalShortcut, _ string, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	p.mu.Lock()
+	p.bound = session
+	p.shortcuts = shortcuts
+	p.mu.Unlock()
+
+	bound := make([]portalShortcut, 0, len(shortcuts))
+	for _, s := range shortcuts {
+		bound = append(bound, portalShortcut{ID: s.ID, Options: map[string]dbus.Variant{
+			"trigger_description": s.Options["preferred_trigger"],
+		}})
+	}
+	path := p.requestPath(sender, options)
+	return path, p.respond(path, map[string]dbus.Variant{"shortcuts": dbus.MakeVariant(bound)})
+}
+
+// fakeProperties answers the version query made before binding.
+type fakeProperties struct{}
+
+func (fakeProperties) Get(iface, prop string) (dbus.Variant, *dbus.Error) {
+	return dbus.MakeVariant(uint32(1)), nil
+}
+
+// emit sends a shortcut signal for id in the handler's session.
+func (p *fakePortal) emit(member string, id string) {
+	p.mu.Lock()
+	session := p.session
+	p.mu.Unlock()
+	p.emitFor(session, member, id)
+}
+
+// emitFor sends a shortcut signal for id in session.
+func (p *fakePortal) emitFor(session dbus.ObjectPath, member string, id string) {
+	p.conn.Emit(portalPath, shortcutsIface+"."+member, session, id, uint64(0), map[string]dbus.Variant{})
+}
+
+func (p *fakePortal) closeSession() {
+	p.mu.Lock()
+	session := p.session
+	p.mu.Unlock()
+	p.conn.Emit(session, sessionIface+".Closed", map[string]dbus.Variant{})
+}
+
+func startFakePortal(t *testing.T, addr string) *fakePortal {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	p := &fakePortal{conn: conn}
+	if err := conn.Export(p, portalPath, shortcutsIface); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Export(fakeProperties{}, portalPath, "org.freedesktop.DBus.Properties"); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := conn.RequestName(portalBusName, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("failed to own %s: %v", portalBusName, err)
+	}
+	return p
+}
+
+// checkShortcuts checks the preferred trigger of each shortcut the portal
+// last bound, by ID.
+func (p *fakePortal) checkShortcuts(t *testing.T, want map[string]string) {
+	t.Helper()
+	p.mu.Lock()
+	shortcuts := p.shortcuts
+	p.mu.Unlock()
+	if len(shortcuts) != len(want) {
+		t.Fatalf("bound %d shortcuts, want %d", len(shortcuts), len(want))
+	}
+	for _, s := range shortcuts {
+		if got, _ := s.Options["preferred_trigger"].Value().(string); got != want[s.ID] {
+			t.Errorf("shortcut %q: preferred trigger %q, want %q", s.ID, got, want[s.ID])
+		}
+	}
+}
+
+// registerWaylandHandler registers a handler for bindings on the bus at
+// addr.
+func registerWaylandHandler(t *testing.T, addr string, bindings []Binding, actions Actions) *WaylandHandler {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newWaylandHandler(conn, bindings, slog.New(slog.DiscardHandler))
+	t.Cleanup(h.Unregister)
+
+	registered := make(chan error, 1)
+	go func() { registered <- h.Register(actions) }()
+	select {
+	case err := <-registered:
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Register did not see the portal responses; the request path was mispredicted")
+	}
+	return h
+}
+
+func TestWaylandHandlerPortal(t *testing.T) {
+	addr := privateBus(t)
+	portal := startFakePortal(t, addr)
+
+	events := make(chan string, 16)
+	actions := Actions{
+		ActionDictate: {
+			Down: func() { events <- "down" },
+			Up:   func() { events <- "up" },
+		},
+		ActionCancel: {
+			Down: func() { events <- "cancel" },
+		},
+	}
+	registerWaylandHandler(t, addr, []Binding{
+		{Trigger: "ctrl+shift+space", Action: ActionDictate},
+		{Trigger: "ctrl+shift+escape", Action: ActionCancel},
+	}, actions)
+	portal.checkShortcuts(t, map[string]string{pushToTalkID: "CTRL+SHIFT+space", ActionCancel: "CTRL+SHIFT+Escape"})
+
+	// Another application's session and IDs Sussurro did not bind are
+	// ignored
+	portal.emitFor(portalPath+"/session/other/app1", "Activated", pushToTalkID)
+	portal.emitFor(portalPath+"/session/other/app1", "Deactivated", pushToTalkID)
+	portal.emit("Activated", "screenshot")
+	portal.emit("Deactivated", "screenshot")
+
+	// Repeated Activated signals press once; Closed releases a held key
+	portal.emit("Activated", pushToTalkID)
+	portal.emit("Activated", pushToTalkID)
+	portal.emit("Deactivated", pushToTalkID)
+	portal.emit("Deactivated", pushToTalkID)
+	portal.emit("Activated", ActionCancel)
+	portal.emit("Activated", pushToTalkID)
+	portal.closeSession()
+
+	var got []string
+	timeout := time.After(5 * time.Second)
+	for len(got) < 5 {
+		select {
+		case ev := <-events:
+			got = append(got, ev)
+		case <-timeout:
+			t.Fatalf("got events %v, want 5", got)
+		}
+	}
+	wantEvents := []string{"down", "up", "cancel", "down", "up"}
+	if strings.Join(got, ",") != strings.Join(wantEvents, ",") {
+		t.Errorf("got events %v, want %v", got, wantEvents)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected extra event %q", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWaylandHandlerSetBindings(t *testing.T) {
+	addr := privateBus(t)
+	portal := startFakePortal(t, addr)
+
+	events := make(chan string, 16)
+	actions := Actions{
+		ActionDictate: {
+			Down: func() { events <- "down" },
+			Up:   func() { events <- "up" },
+		},
+	}
+	h := registerWaylandHandler(t, addr, []Binding{{Trigger: "ctrl+shift+space", Action: ActionDictate}}, actions)
+	portal.mu.Lock()
+	session := portal.session
+	portal.mu.Unlock()
+
+	portal.emit("Activated", pushToTalkID)
+	select {
+	case ev := <-events:
+		if ev != "down" {
+			t.Fatalf("got event %q, want down", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no press")
+	}
+
+	// The held key is released, and the new trigger bound in the same session
+	if err := h.SetBindings([]Binding{{Trigger: "super+d", Action: ActionDictate}}); err != nil {
+		t.Fatalf("SetBindings: %v", err)
+	}
+	select {
+	case ev := <-events:
+		if ev != "up" {
+			t.Errorf("got event %q, want up", ev)
+		}
+	default:
+		t.Error("SetBindings did not release the held key")
+	}
+	portal.checkShortcuts(t, map[string]string{pushToTalkID: "LOGO+d"})
+	portal.mu.Lock()
+	bound := portal.bound
+	portal.mu.Unlock()
+	if bound != session {
+		t.Errorf("bound in session %q, want %q", bound, session)
+	}
+}
@@ -0,0 +1,303 @@
+package hotkey
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyDef describes a trigger key on each platform.
+type keyDef struct {
+	keysym uint32 // X11 keysym, used by the X11 grabs
+	evdev  uint16 // Linux input event code, used by the evdev backend
+	mac    int    // macOS virtual key code, -1 when the key has none
+	xkb    string // XKB keysym name, used by the GlobalShortcuts portal
+	mod    string // for modifier keys, the modifier the key holds
+}
+
+// noMac marks keys that macOS hotkeys cannot register.
+const noMac = -1
+
+// keyTable lists every key a trigger can name. The X11 grabs (headless and
+// overlay), the macOS handler, the evdev backend, and the portal all read
+// their key codes from it, so a trigger means the same key everywhere.
+// evdev codes are physical positions, so letters and punctuation are
+// matched where they sit on a US QWERTY keyboard.
+var keyTable = map[string]keyDef{
+	// Letters
+	"a": {0x61, 30, 0x00, "a", ""},
+	"b": {0x62, 48, 0x0b, "b", ""},
+	"c": {0x63, 46, 0x08, "c", ""},
+	"d": {0x64, 32, 0x02, "d", ""},
+	"e": {0x65, 18, 0x0e, "e", ""},
+	"f": {0x66, 33, 0x03, "f", ""},
+	"g": {0x67, 34, 0x05, "g", ""},
+	"h": {0x68, 35, 0x04, "h", ""},
+	"i": {0x69, 23, 0x22, "i", ""},
+	"j": {0x6a, 36, 0x26, "j", ""},
+	"k": {0x6b, 37, 0x28, "k", ""},
+	"l": {0x6c, 38, 0x25, "l", ""},
+	"m": {0x6d, 50, 0x2e, "m", ""},
+	"n": {0x6e, 49, 0x2d, "n", ""},
+	"o": {0x6f, 24, 0x1f, "o", ""},
+	"p": {0x70, 25, 0x23, "p", ""},
+	"q": {0x71, 16, 0x0c, "q", ""},
+	"r": {0x72, 19, 0x0f, "r", ""},
+	"s": {0x73, 31, 0x01, "s", ""},
+	"t": {0x74, 20, 0x11, "t", ""},
+	"u": {0x75, 22, 0x20, "u", ""},
+	"v": {0x76, 47, 0x09, "v", ""},
+	"w": {0x77, 17, 0x0d, "w", ""},
+	"x": {0x78, 45, 0x07, "x", ""},
+	"y": {0x79, 21, 0x10, "y", ""},
+	"z": {0x7a, 44, 0x06, "z", ""},
+
+	// Digits
+	"0": {0x30, 11, 0x1d, "0", ""},
+	"1": {0x31, 2, 0x12, "1", ""},
+	"2": {0x32, 3, 0x13, "2", ""},
+	"3": {0x33, 4, 0x14, "3", ""},
+	"4": {0x34, 5, 0x15, "4", ""},
+	"5": {0x35, 6, 0x17, "5", ""},
+	"6": {0x36, 7, 0x16, "6", ""},
+	"7": {0x37, 8, 0x1a, "7", ""},
+	"8": {0x38, 9, 0x1c, "8", ""},
+	"9": {0x39, 10, 0x19, "9", ""},
+
+	// Punctuation
+	"minus":        {0x2d, 12, 0x1b, "minus", ""},
+	"equal":        {0x3d, 13, 0x18, "equal", ""},
+	"leftbracket":  {0x5b, 26, 0x21, "bracketleft", ""},
+	"rightbracket": {0x5d, 27, 0x1e, "bracketright", ""},
+	"semicolon":    {0x3b, 39, 0x29, "semicolon", ""},
+	"apostrophe":   {0x27, 40, 0x27, "apostrophe", ""},
+	"grave":        {0x60, 41, 0x32, "grave", ""},
+	"backslash":    {0x5c, 43, 0x2a, "backslash", ""},
+	"comma":        {0x2c, 51, 0x2b, "comma", ""},
+	"period":       {0x2e, 52, 0x2f, "period", ""},
+	"slash":        {0x2f, 53, 0x2c, "slash", ""},
+
+	// Editing and navigation
+	"space":       {0x0020, 57, 0x31, "space", ""},
+	"enter":       {0xff0d, 28, 0x24, "Return", ""},
+	"tab":         {0xff09, 15, 0x30, "Tab", ""},
+	"escape":      {0xff1b, 1, 0x35, "Escape", ""},
+	"backspace":   {0xff08, 14, 0x33, "BackSpace", ""},
+	"delete":      {0xffff, 111, 0x75, "Delete", ""},
+	"insert":      {0xff63, 110, 0x72, "Insert", ""}, // Help on Apple keyboards
+	"home":        {0xff50, 102, 0x73, "Home", ""},
+	"end":         {0xff57, 107, 0x77, "End", ""},
+	"pageup":      {0xff55, 104, 0x74, "Prior", ""},
+	"pagedown":    {0xff56, 109, 0x79, "Next", ""},
+	"up":          {0xff52, 103, 0x7e, "Up", ""},
+	"down":        {0xff54, 108, 0x7d, "Down", ""},
+	"left":        {0xff51, 105, 0x7b, "Left", ""},
+	"right":       {0xff53, 106, 0x7c, "Right", ""},
+	"pause":       {0xff13, 119, noMac, "Pause", ""},
+	"scrolllock":  {0xff14, 70, noMac, "Scroll_Lock", ""},
+	"printscreen": {0xff61, 99, noMac, "Print", ""},
+	"menu":        {0xff67, 127, noMac, "Menu", ""},
+	"capslock":    {0xffe5, 58, 0x39, "Caps_Lock", ""},
+
+	// Function keys
+	"f1":  {0xffbe, 59, 0x7a, "F1", ""},
+	"f2":  {0xffbf, 60, 0x78, "F2", ""},
+	"f3":  {0xffc0, 61, 0x63, "F3", ""},
+	"f4":  {0xffc1, 62, 0x76, "F4", ""},
+	"f5":  {0xffc2, 63, 0x60, "F5", ""},
+	"f6":  {0xffc3, 64, 0x61, "F6", ""},
+	"f7":  {0xffc4, 65, 0x62, "F7", ""},
+	"f8":  {0xffc5, 66, 0x64, "F8", ""},
+	"f9":  {0xffc6, 67, 0x65, "F9", ""},
+	"f10": {0xffc7, 68, 0x6d, "F10", ""},
+	"f11": {0xffc8, 87, 0x67, "F11", ""},
+	"f12": {0xffc9, 88, 0x6f, "F12", ""},
+	"f13": {0xffca, 183, 0x69, "F13", ""},
+	"f14": {0xffcb, 184, 0x6b, "F14", ""},
+	"f15": {0xffcc, 185, 0x71, "F15", ""},
+	"f16": {0xffcd, 186, 0x6a, "F16", ""},
+	"f17": {0xffce, 187, 0x40, "F17", ""},
+	"f18": {0xffcf, 188, 0x4f, "F18", ""},
+	"f19": {0xffd0, 189, 0x50, "F19", ""},
+	"f20": {0xffd1, 190, 0x5a, "F20", ""},
+	"f21": {0xffd2, 191, noMac, "F21", ""},
+	"f22": {0xffd3, 192, noMac, "F22", ""},
+	"f23": {0xffd4, 193, noMac, "F23", ""},
+	"f24": {0xffd5, 194, noMac, "F24", ""},
+
+	// Numeric keypad (the NumLock-on symbols)
+	"kp0":        {0xffb0, 82, 0x52, "KP_0", ""},
+	"kp1":        {0xffb1, 79, 0x53, "KP_1", ""},
+	"kp2":        {0xffb2, 80, 0x54, "KP_2", ""},
+	"kp3":        {0xffb3, 81, 0x55, "KP_3", ""},
+	"kp4":        {0xffb4, 75, 0x56, "KP_4", ""},
+	"kp5":        {0xffb5, 76, 0x57, "KP_5", ""},
+	"kp6":        {0xffb6, 77, 0x58, "KP_6", ""},
+	"kp7":        {0xffb7, 71, 0x59, "KP_7", ""},
+	"kp8":        {0xffb8, 72, 0x5b, "KP_8", ""},
+	"kp9":        {0xffb9, 73, 0x5c, "KP_9", ""},
+	"kpdot":      {0xffae, 83, 0x41, "KP_Decimal", ""},
+	"kpslash":    {0xffaf, 98, 0x4b, "KP_Divide", ""},
+	"kpasterisk": {0xffaa, 55, 0x43, "KP_Multiply", ""},
+	"kpminus":    {0xffad, 74, 0x4e, "KP_Subtract", ""},
+	"kpplus":     {0xffab, 78, 0x45, "KP_Add", ""},
+	"kpenter":    {0xff8d, 96, 0x4c, "KP_Enter", ""},
+
+	// Media keys
+	"playpause":  {0x1008ff14, 164, noMac, "XF86AudioPlay", ""},
+	"mediastop":  {0x1008ff15, 166, noMac, "XF86AudioStop", ""},
+	"prevtrack":  {0x1008ff16, 165, noMac, "XF86AudioPrev", ""},
+	"nexttrack":  {0x1008ff17, 163, noMac, "XF86AudioNext", ""},
+	"mute":       {0x1008ff12, 113, 0x4a, "XF86AudioMute", ""},
+	"volumedown": {0x1008ff11, 114, 0x49, "XF86AudioLowerVolume", ""},
+	"volumeup":   {0x1008ff13, 115, 0x48, "XF86AudioRaiseVolume", ""},
+	"micmute":    {0x1008ffb2, 248, noMac, "XF86AudioMicMute", ""},
+
+	// Modifier keys, for modifier-only and double-tap triggers
+	"lctrl":  {0xffe3, 29, 0x3b, "Control_L", "ctrl"},
+	"rctrl":  {0xffe4, 97, 0x3e, "Control_R", "ctrl"},
+	"lshift": {0xffe1, 42, 0x38, "Shift_L", "shift"},
+	"rshift": {0xffe2, 54, 0x3c, "Shift_R", "shift"},
+	"lalt":   {0xffe9, 56, 0x3a, "Alt_L", "alt"},
+	"ralt":   {0xffea, 100, 0x3d, "Alt_R", "alt"},
+	"lsuper": {0xffeb, 125, 0x37, "Super_L", "super"},
+	"rsuper": {0xffec, 126, 0x36, "Super_R", "super"},
+}
+
+// keyAliases maps other spellings of a key to its name in keyTable.
+var keyAliases = map[string]string{
+	"return":       "enter",
+	"esc":          "escape",
+	"del":          "delete",
+	"ins":          "insert",
+	"pgup":         "pageup",
+	"pgdn":         "pagedown",
+	"print":        "printscreen",
+	"-":            "minus",
+	"=":            "equal",
+	"[":            "leftbracket",
+	"]":            "rightbracket",
+	";":            "semicolon",
+	"'":            "apostrophe",
+	"`":            "grave",
+	"\\":           "backslash",
+	",":            "comma",
+	".":            "period",
+	"/":            "slash",
+	"bracketleft":  "leftbracket",
+	"bracketright": "rightbracket",
+	"quote":        "apostrophe",
+	"dot":          "period",
+	"arrowup":      "up",
+	"arrowdown":    "down",
+	"arrowleft":    "left",
+	"arrowright":   "right",
+	"rightctrl":    "rctrl",
+	"leftctrl":     "lctrl",
+	"rightshift":   "rshift",
+	"leftshift":    "lshift",
+	"rightalt":     "ralt",
+	"leftalt":      "lalt",
+	"altgr":        "ralt",
+	"rightsuper":   "rsuper",
+	"leftsuper":    "lsuper",
+	"rcmd":         "rsuper",
+	"lcmd":         "lsuper",
+}
+
+// triggerModifiers lists the modifier aliases in canonical order.
+var triggerModifiers = []struct {
+	name    string
+	aliases []string
+}{
+	{"ctrl", []string{"ctrl", "control"}},
+	{"shift", []string{"shift"}},
+	{"alt", []string{"alt", "option"}},
+	{"super", []string{"super", "cmd", "command", "meta"}},
+}
+
+// doublePrefix starts a double-tap trigger, such as "double+rctrl".
+const doublePrefix = "double"
+
+// chord is a parsed trigger.
+type chord struct {
+	mods   []string // canonical modifiers, in the order ctrl, shift, alt, super
+	key    string   // name in keyTable
+	def    keyDef
+	double bool // fires on the second of two quick taps of key
+}
+
+// modifierOnly reports whether the chord's key is itself a modifier key,
+// like "rctrl" or "ctrl+rshift".
+func (c chord) modifierOnly() bool {
+	return c.def.mod != ""
+}
+
+// raw reports whether the chord can only be detected from raw key events,
+// which key grabs and desktop shortcuts do not provide.
+func (c chord) raw() bool {
+	return c.double || c.modifierOnly()
+}
+
+// String returns the chord in canonical trigger form.
+func (c chord) String() string {
+	parts := append([]string(nil), c.mods...)
+	if c.double {
+		parts = append([]string{doublePrefix}, parts...)
+	}
+	return strings.Join(append(parts, c.key), "+")
+}
+
+// parseChord parses a trigger such as "ctrl+shift+space", "rctrl", or
+// "double+rctrl".
+func parseChord(trigger string) (chord, error) {
+	parts := strings.Split(strings.ToLower(strings.ReplaceAll(trigger, " ", "")), "+")
+
+	var c chord
+	if len(parts) > 1 && parts[0] == doublePrefix {
+		c.double = true
+		parts = parts[1:]
+		if len(parts) != 1 {
+			return chord{}, fmt.Errorf("a double-tap trigger takes a single key, like double+rctrl: %s", trigger)
+		}
+	}
+
+	key := parts[len(parts)-1]
+	if key == "" {
+		return chord{}, fmt.Errorf("empty hotkey trigger")
+	}
+	if alias, ok := keyAliases[key]; ok {
+		key = alias
+	}
+	def, ok := keyTable[key]
+	if !ok {
+		return chord{}, fmt.Errorf("unknown key: %s", key)
+	}
+	c.key, c.def = key, def
+
+	held := make(map[string]bool)
+parts:
+	for _, part := range parts[:len(parts)-1] {
+		for _, m := range triggerModifiers {
+			for _, alias := range m.aliases {
+				if part == alias {
+					held[m.name] = true
+					continue parts
+				}
+			}
+		}
+		return chord{}, fmt.Errorf("unknown modifier: %s", part)
+	}
+	if held[def.mod] {
+		return chord{}, fmt.Errorf("%s is both the key and a modifier in %s", key, trigger)
+	}
+	for _, m := range triggerModifiers {
+		if held[m.name] {
+			c.mods = append(c.mods, m.name)
+		}
+	}
+
+	if c.raw() && !rawTriggersSupported {
+		return chord{}, fmt.Errorf("modifier-only and double-tap triggers are not supported on this platform: %s", trigger)
+	}
+	return c, nil
+}
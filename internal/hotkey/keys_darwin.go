@@ -0,0 +1,30 @@
+//go:build darwin
+
+package hotkey
+
+import "fmt"
+
+// rawTriggersSupported is false: macOS hotkeys are registered chords, and
+// there is no raw key event backend.
+const rawTriggersSupported = false
+
+// MacKey returns the virtual key code and the canonical modifiers a macOS
+// hotkey for trigger registers.
+func MacKey(trigger string) (keycode int, mods []string, err error) {
+	c, err := parseChord(trigger)
+	if err != nil {
+		return 0, nil, err
+	}
+	if c.raw() {
+		return 0, nil, errRawChord(c)
+	}
+	if c.def.mac == noMac {
+		return 0, nil, fmt.Errorf("key %s cannot be registered as a hotkey on macOS", c.key)
+	}
+	return c.def.mac, c.mods, nil
+}
+
+// errRawChord explains that c needs raw key events.
+func errRawChord(c chord) error {
+	return fmt.Errorf("%s: modifier-only and double-tap triggers are not supported on macOS", c)
+}
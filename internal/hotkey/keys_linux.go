@@ -0,0 +1,39 @@
+//go:build linux
+
+package hotkey
+
+import "fmt"
+
+// rawTriggersSupported is true because the evdev backend sees raw key
+// events on Linux.
+const rawTriggersSupported = true
+
+// x11Modifiers maps canonical modifiers to X11 modifier masks. Mod1 is Alt
+// and Mod4 is Super on virtually all X11 systems.
+var x11Modifiers = map[string]uint32{
+	"shift": 1 << 0, // ShiftMask
+	"ctrl":  1 << 2, // ControlMask
+	"alt":   1 << 3, // Mod1Mask
+	"super": 1 << 6, // Mod4Mask
+}
+
+// X11Key returns the keysym and modifier mask an X11 grab for trigger uses.
+// Modifier-only and double-tap triggers cannot be grabbed.
+func X11Key(trigger string) (keysym, mods uint32, err error) {
+	c, err := parseChord(trigger)
+	if err != nil {
+		return 0, 0, err
+	}
+	if c.raw() {
+		return 0, 0, errRawChord(c)
+	}
+	for _, m := range c.mods {
+		mods |= x11Modifiers[m]
+	}
+	return c.def.keysym, mods, nil
+}
+
+// errRawChord explains that c needs raw key events.
+func errRawChord(c chord) error {
+	return fmt.Errorf("%s needs raw key events, which Sussurro reads from /dev/input", c)
+}
@@ -0,0 +1,158 @@
+package hotkey
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cesp99/sussurro/internal/appstate"
+)
+
+// Modes selectable with hotkey.mode.
+const (
+	ModeHold   = "hold"   // record while the key is held
+	ModeToggle = "toggle" // press to start, press again to stop
+	ModeLatch  = "latch"  // hold to talk, or tap to keep recording until the next press
+)
+
+// DefaultTapThreshold separates a tap from a hold in latch mode.
+const DefaultTapThreshold = 300 * time.Millisecond
+
+// ParseMode normalises a hotkey.mode value. The empty string means hold.
+func ParseMode(mode string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(mode)); m {
+	case "":
+		return ModeHold, nil
+	case ModeHold, ModeToggle, ModeLatch:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown hotkey.mode %q (use hold, toggle, or latch)", mode)
+	}
+}
+
+// ParseTapThreshold parses hotkey.tap_threshold. The empty string means
+// DefaultTapThreshold.
+func ParseTapThreshold(threshold string) (time.Duration, error) {
+	if strings.TrimSpace(threshold) == "" {
+		return DefaultTapThreshold, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(threshold))
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid hotkey.tap_threshold %q", threshold)
+	}
+	return d, nil
+}
+
+// Recorder is the part of the pipeline driven by the hotkey.
+type Recorder interface {
+	StartRecording() bool
+	StopRecording() bool
+	CancelRecording() bool
+	State() appstate.State
+}
+
+// Dispatcher turns presses and releases of the trigger into recording
+// starts and stops according to the hotkey mode. Every hotkey backend and
+// the control socket's press/release commands feed the same Dispatcher, so
+// the mode behaves the same everywhere.
+type Dispatcher struct {
+	rec          Recorder
+	log          *slog.Logger
+	mode         string
+	tapThreshold time.Duration
+
+	mu        sync.Mutex
+	down      bool // a press has not been released yet
+	pressedAt time.Time
+	started   bool // the current press started a recording
+}
+
+// NewDispatcher creates a Dispatcher for mode. A zero tapThreshold means
+// DefaultTapThreshold.
+func NewDispatcher(rec Recorder, mode string, tapThreshold time.Duration, log *slog.Logger) *Dispatcher {
+	if tapThreshold <= 0 {
+		tapThreshold = DefaultTapThreshold
+	}
+	return &Dispatcher{rec: rec, log: log, mode: mode, tapThreshold: tapThreshold}
+}
+
+// KeyDown handles a press of the trigger.
+func (d *Dispatcher) KeyDown() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Backends drop autorepeat themselves. A second press without a
+	// release is still honoured, since some desktop shortcuts only send
+	// presses to the control socket.
+	d.down = true
+	d.pressedAt = time.Now()
+	d.started = false
+
+	switch d.mode {
+	case ModeToggle, ModeLatch:
+		// A press while recording always ends it; in latch mode this is
+		// the press that releases the lock
+		if d.rec.State() == appstate.Recording {
+			d.stop()
+			return
+		}
+	}
+	d.started = d.start()
+}
+
+// KeyUp handles a release of the trigger.
+func (d *Dispatcher) KeyUp() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.down {
+		return
+	}
+	d.down = false
+
+	switch d.mode {
+	case ModeToggle:
+		return
+	case ModeLatch:
+		if !d.started {
+			return
+		}
+		if time.Since(d.pressedAt) < d.tapThreshold {
+			d.log.Info("Recording latched - press the hotkey again to stop")
+			return
+		}
+	}
+	d.stop()
+}
+
+// KeyAbort handles a press that turned out to be part of another shortcut,
+// such as a modifier-only trigger followed by another key. A recording the
+// press started is discarded.
+func (d *Dispatcher) KeyAbort() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.down {
+		return
+	}
+	d.down = false
+	if d.started && d.rec.CancelRecording() {
+		d.log.Info("Recording cancelled - the hotkey was part of a shortcut")
+	}
+}
+
+func (d *Dispatcher) start() bool {
+	if !d.rec.StartRecording() {
+		return false
+	}
+	d.log.Info("Listening...")
+	return true
+}
+
+func (d *Dispatcher) stop() {
+	if d.rec.StopRecording() {
+		d.log.Info("Transcribing...")
+	}
+}
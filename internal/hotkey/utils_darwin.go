@@ -2,7 +2,51 @@
 
 package hotkey
 
+import (
+	"errors"
+	"log/slog"
+)
+
 // IsWayland checks if we're running on Wayland (always false on macOS)
 func IsWayland() bool {
 	return false
 }
+
+// WaylandHandler is the Linux GlobalShortcuts portal handler; macOS has no
+// Wayland, so it is never created there.
+type WaylandHandler struct{}
+
+// NewWaylandHandler always fails on macOS.
+func NewWaylandHandler(bindings []Binding, log *slog.Logger) (*WaylandHandler, error) {
+	return nil, errors.New("the GlobalShortcuts portal is only available on Linux")
+}
+
+// Register is never reached on macOS.
+func (h *WaylandHandler) Register(actions Actions) error {
+	return errors.New("the GlobalShortcuts portal is only available on Linux")
+}
+
+// Unregister is a no-op on macOS.
+func (h *WaylandHandler) Unregister() {}
+
+// EvdevHandler reads Linux input devices; macOS has none, so it is never
+// created there.
+type EvdevHandler struct{}
+
+// NewEvdevHandler always fails on macOS.
+func NewEvdevHandler(bindings []Binding, log *slog.Logger) (*EvdevHandler, error) {
+	return nil, errors.New("the evdev hotkey backend is only available on Linux")
+}
+
+// Register is never reached on macOS.
+func (h *EvdevHandler) Register(actions Actions) error {
+	return errors.New("the evdev hotkey backend is only available on Linux")
+}
+
+// SetBindings is never reached on macOS.
+func (h *EvdevHandler) SetBindings(bindings []Binding) error {
+	return errors.New("the evdev hotkey backend is only available on Linux")
+}
+
+// Unregister is a no-op on macOS.
+func (h *EvdevHandler) Unregister() {}
@@ -0,0 +1,77 @@
+package injection
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// chord is a key combination sent to paste, e.g. Ctrl+Shift+V.
+type chord struct {
+	ctrl, shift, alt, super bool
+	key                     pasteKey
+	name                    string
+}
+
+// pasteKey is a key a paste chord may end with.
+type pasteKey struct {
+	code int  // keybd_event code of the key on a US QWERTY layout
+	char rune // character the key types, or 0 if it does not move between layouts
+}
+
+func (c chord) String() string { return c.name }
+
+// defaultPasteChord is the platform paste shortcut.
+func defaultPasteChord() chord {
+	if runtime.GOOS == "darwin" {
+		c, _ := parseChord("cmd+v")
+		return c
+	}
+	c, _ := parseChord("ctrl+v")
+	return c
+}
+
+// parseChord parses a string like "ctrl+shift+v" or "shift+insert".
+// The supported keys are listed in pasteKeys.
+func parseChord(s string) (chord, error) {
+	parts := strings.Split(strings.ToLower(strings.ReplaceAll(s, " ", "")), "+")
+	c := chord{name: strings.Join(parts, "+")}
+
+	for i, part := range parts {
+		if i == len(parts)-1 {
+			key, ok := pasteKeys[part]
+			if !ok {
+				return chord{}, fmt.Errorf("unsupported paste key %q in %q", part, s)
+			}
+			c.key = key
+			continue
+		}
+		switch part {
+		case "ctrl", "control":
+			c.ctrl = true
+		case "shift":
+			c.shift = true
+		case "alt", "option":
+			c.alt = true
+		case "super", "cmd", "command", "meta":
+			c.super = true
+		default:
+			return chord{}, fmt.Errorf("unknown modifier %q in %q", part, s)
+		}
+	}
+	return c, nil
+}
+
+// keyCode returns the keybd_event code to press for the chord's key. Letter
+// keys are looked up in the active keyboard layout so that, for example,
+// Ctrl+V still pastes on Dvorak; the QWERTY position is used when the layout
+// cannot be queried.
+func (c chord) keyCode() (code int, resolved bool) {
+	if c.key.char == 0 {
+		return c.key.code, true
+	}
+	if code, ok := layoutKeyCode(c.key.char, c.super); ok {
+		return code, true
+	}
+	return c.key.code, false
+}
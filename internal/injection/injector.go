@@ -2,87 +2,201 @@ package injection
 
 import (
 	"fmt"
-	"runtime"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/micmonay/keybd_event"
+	"github.com/cesp99/sussurro/internal/clipboard"
+	"github.com/cesp99/sussurro/internal/config"
+	ctxProvider "github.com/cesp99/sussurro/internal/context"
 )
 
-// Injector handles text injection into the active application
-type Injector struct {
-	kb keybd_event.KeyBonding
+// defaultRestoreDelay is how long the transcript stays on the clipboard
+// before the previous contents are restored, when restore_delay is unset.
+const defaultRestoreDelay = 500 * time.Millisecond
+
+// Injection methods accepted by injection.method.
+const (
+	MethodPaste     = "paste"     // clipboard + paste shortcut
+	MethodType      = "type"      // synthesized keystrokes, clipboard untouched
+	MethodClipboard = "clipboard" // copy only, the user pastes
+	MethodStdout    = "stdout"    // print to standard output
+	MethodNone      = "none"      // history and events only
+
+	// methodKeyboard is the name used for MethodPaste up to 1.6.
+	methodKeyboard = "keyboard"
+)
+
+// Keystroke backends accepted by injection.keyboard (Linux only).
+const (
+	KeyboardAuto   = "auto"   // uinput on Wayland, tools on X11, each falling back to the other
+	KeyboardUinput = "uinput" // the /dev/uinput virtual keyboard
+	KeyboardTools  = "tools"  // xdotool, wtype, or ydotool
+)
+
+// Policies for injection.on_target_closed, applied when the window a
+// dictation was recorded for has closed before the text is ready.
+const (
+	TargetClosedFocused   = "focused"   // inject into the window focused now
+	TargetClosedClipboard = "clipboard" // only copy the text
+	TargetClosedDiscard   = "discard"   // drop it; it is still in the history
+)
+
+// Injector delivers the final text to the user.
+type Injector interface {
+	// Inject outputs text for the target window, applying the first
+	// matching app rule. target may be nil.
+	Inject(text string, target *ctxProvider.ContextInfo) error
+	// Method returns the default injection method name.
+	Method() string
+	// MethodFor returns the method Inject uses for target, after app rules.
+	MethodFor(target *ctxProvider.ContextInfo) string
 }
 
-// NewInjector creates a new text injector
-func NewInjector() (*Injector, error) {
-	kb, err := keybd_event.NewKeyBonding()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create key bonding: %w", err)
-	}
+// output is a single injection method.
+type output interface {
+	Inject(text string) error
+	Method() string
+}
 
-	// For Linux, we might need to set the device path, but for now we assume standard setup
-	if runtime.GOOS == "linux" {
-		// potential TODO: handle linux specific setup
+// ParseMethod normalises an injection.method value. The empty string and
+// the legacy "keyboard" both mean paste.
+func ParseMethod(method string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(method)); m {
+	case "", methodKeyboard:
+		return MethodPaste, nil
+	case MethodPaste, MethodType, MethodClipboard, MethodStdout, MethodNone:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown injection method %q (use paste, type, clipboard, stdout, or none)", method)
 	}
+}
 
-	return &Injector{kb: kb}, nil
+// ParseTargetClosed normalises an injection.on_target_closed value. The
+// empty string means focused.
+func ParseTargetClosed(policy string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(policy)); p {
+	case "":
+		return TargetClosedFocused, nil
+	case TargetClosedFocused, TargetClosedClipboard, TargetClosedDiscard:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown injection.on_target_closed %q (use focused, clipboard, or discard)", policy)
+	}
 }
 
-// TypeString simulates typing the given string
-// Note: keybd_event is limited in character support. 
-// For complex text, we might want to use clipboard + paste.
-func (i *Injector) TypeString(text string) error {
-	// For macOS, we can use the clipboard approach for reliability with special chars
-	// 1. Copy text to clipboard
-	// 2. Simulate Cmd+V
-	
-	// However, the interface asks for injection. 
-	// Let's implement a hybrid approach:
-	// If the text is simple, maybe type it? 
-	// Actually, pasting is almost always faster and more reliable for blocks of text.
-	
-	return i.Paste()
+func parseKeyboard(keyboard string) (string, error) {
+	switch k := strings.ToLower(strings.TrimSpace(keyboard)); k {
+	case "":
+		return KeyboardAuto, nil
+	case KeyboardAuto, KeyboardUinput, KeyboardTools:
+		return k, nil
+	default:
+		return "", fmt.Errorf("unknown injection.keyboard %q (use auto, uinput, or tools)", keyboard)
+	}
 }
 
-// Paste simulates the paste command (Cmd+V on Mac, Ctrl+V on others)
-func (i *Injector) Paste() error {
-	// Set keys for Paste command
-	if runtime.GOOS == "darwin" {
-		i.kb.SetKeys(keybd_event.VK_V)
-		i.kb.HasSuper(true)
-	} else {
-		i.kb.SetKeys(keybd_event.VK_V)
-		i.kb.HasCTRL(true)
+// NewInjector creates the injector selected by cfg.Method, with the
+// per-app rules from cfg.Apps and the built-in terminal rules.
+func NewInjector(cfg config.InjectionConfig, log *slog.Logger) (Injector, error) {
+	m, err := ParseMethod(cfg.Method)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(strings.TrimSpace(cfg.Method), methodKeyboard) {
+		log.Info("injection.method \"keyboard\" is now called \"paste\"")
 	}
 
-	// Press and Release
-	err := i.kb.Launching()
+	keyboard, err := parseKeyboard(cfg.Keyboard)
 	if err != nil {
-		return fmt.Errorf("failed to simulate paste: %w", err)
+		return nil, err
+	}
+
+	var restoreDelay time.Duration
+	if cfg.RestoreClipboard {
+		restoreDelay = defaultRestoreDelay
+		if cfg.RestoreDelay != "" {
+			d, err := time.ParseDuration(cfg.RestoreDelay)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid injection.restore_delay %q", cfg.RestoreDelay)
+			}
+			restoreDelay = d
+		}
 	}
-	
-	// Reset modifiers
-	i.kb.HasSuper(false)
-	i.kb.HasCTRL(false)
-	
-	return nil
+
+	// One instance per method, shared by the default and all rules
+	outputs := make(map[string]output)
+	newOutput := func(method string) (output, error) {
+		if out, ok := outputs[method]; ok {
+			return out, nil
+		}
+		out, err := createOutput(method, keyboard, restoreDelay, log)
+		if err != nil {
+			return nil, err
+		}
+		outputs[method] = out
+		return out, nil
+	}
+
+	def, err := newOutput(m)
+	if err != nil {
+		return nil, err
+	}
+	return newRouter(def, cfg, newOutput, log)
+}
+
+func createOutput(method, keyboard string, restoreDelay time.Duration, log *slog.Logger) (output, error) {
+	switch method {
+	case MethodPaste:
+		out, err := newPasteInjector(restoreDelay, log)
+		if err != nil {
+			return nil, err
+		}
+		return out, nil
+	case MethodType:
+		return newTypeInjector(keyboard, log)
+	case MethodClipboard:
+		return clipboardInjector{}, nil
+	case MethodStdout:
+		return &stdoutInjector{}, nil
+	default:
+		return noneInjector{}, nil
+	}
+}
+
+// isWayland reports whether we are running in a Wayland session.
+func isWayland() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("XDG_SESSION_TYPE") == "wayland"
 }
 
-// Inject simulates typing or pasting text.
-// Currently defaults to clipboard paste as it's more robust for AI output.
-func (i *Injector) Inject(text string) error {
-	// We assume the caller has already placed the text in the clipboard 
-	// or we should handle it here. 
-	// Ideally, this package should handle the clipboard write too to be self-contained.
-	// But `clipboard` is in a separate package. 
-	// Let's assume the pipeline handles clipboard write, and this handles the keypress.
-	// OR we import internal/clipboard here.
-	
-	// Let's make this method do the pasting action.
-	// The pipeline currently writes to clipboard.
-	
-	// Add a small delay to ensure clipboard is ready and window is focused
-	time.Sleep(100 * time.Millisecond)
-	
-	return i.Paste()
+// clipboardInjector copies the text and leaves pasting to the user.
+type clipboardInjector struct{}
+
+func (clipboardInjector) Method() string { return MethodClipboard }
+
+func (clipboardInjector) Inject(text string) error {
+	return clipboard.Write(text)
 }
+
+// stdoutInjector prints each dictation on its own line, for headless use
+// where another program consumes the output.
+type stdoutInjector struct {
+	mu sync.Mutex
+}
+
+func (*stdoutInjector) Method() string { return MethodStdout }
+
+func (i *stdoutInjector) Inject(text string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	_, err := fmt.Fprintln(os.Stdout, text)
+	return err
+}
+
+// noneInjector discards the text; it still reaches history and subscribers.
+type noneInjector struct{}
+
+func (noneInjector) Method() string        { return MethodNone }
+func (noneInjector) Inject(_ string) error { return nil }
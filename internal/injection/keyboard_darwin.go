@@ -0,0 +1,37 @@
+//go:build darwin
+
+package injection
+
+import (
+	"fmt"
+
+	"github.com/micmonay/keybd_event"
+)
+
+// keybdKeyboard presses chords with keybd_event, which posts CGEvents.
+type keybdKeyboard struct {
+	kb keybd_event.KeyBonding
+}
+
+func newChordKeyboard() (chordKeyboard, error) {
+	kb, err := keybd_event.NewKeyBonding()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key bonding: %w", err)
+	}
+	return &keybdKeyboard{kb: kb}, nil
+}
+
+func (k *keybdKeyboard) pressChord(code int, keys chord) error {
+	k.kb.Clear()
+	k.kb.SetKeys(code)
+	k.kb.HasCTRL(keys.ctrl)
+	k.kb.HasSHIFT(keys.shift)
+	k.kb.HasALT(keys.alt)
+	k.kb.HasSuper(keys.super)
+
+	err := k.kb.Launching()
+
+	// Reset modifiers
+	k.kb.Clear()
+	return err
+}
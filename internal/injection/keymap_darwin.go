@@ -0,0 +1,71 @@
+//go:build darwin
+
+package injection
+
+/*
+#cgo LDFLAGS: -framework Carbon
+
+#include <Carbon/Carbon.h>
+#include <dispatch/dispatch.h>
+#include <pthread.h>
+
+static int scanLayout(UniChar c, UInt32 modifiers) {
+    TISInputSourceRef source = TISCopyCurrentKeyboardLayoutInputSource();
+    if (source == NULL) {
+        return -1;
+    }
+
+    int found = -1;
+    CFDataRef data = TISGetInputSourceProperty(source, kTISPropertyUnicodeKeyLayoutData);
+    if (data != NULL) {
+        const UCKeyboardLayout *layout = (const UCKeyboardLayout *)CFDataGetBytePtr(data);
+        for (UInt16 kc = 0; kc < 128 && found < 0; kc++) {
+            UInt32 deadKeys = 0;
+            UniChar out[4];
+            UniCharCount len = 0;
+            OSStatus status = UCKeyTranslate(layout, kc, kUCKeyActionDown, (modifiers >> 8) & 0xFF,
+                                             LMGetKbdType(), kUCKeyTranslateNoDeadKeysMask,
+                                             &deadKeys, 4, &len, out);
+            if (status == noErr && len == 1 && out[0] == c) {
+                found = kc;
+            }
+        }
+    }
+    CFRelease(source);
+    return found;
+}
+
+// Returns the virtual key code that types c in the current keyboard layout
+// with the given Carbon modifiers held, or -1. Text Input Sources must be
+// queried on the main thread.
+int findKeyCode(UniChar c, UInt32 modifiers) {
+    if (pthread_main_np()) {
+        return scanLayout(c, modifiers);
+    }
+    __block int found = -1;
+    dispatch_sync(dispatch_get_main_queue(), ^{
+        found = scanLayout(c, modifiers);
+    });
+    return found;
+}
+*/
+import "C"
+
+// layoutKeyCode returns the virtual key code that types r in the current
+// keyboard layout. With cmd set the layout is asked what the key types while
+// Command is held, which keeps layouts like "Dvorak - QWERTY ⌘" on their
+// QWERTY shortcut keys.
+func layoutKeyCode(r rune, cmd bool) (code int, ok bool) {
+	if r > 0xffff {
+		return 0, false
+	}
+	var modifiers C.UInt32
+	if cmd {
+		modifiers = C.cmdKey
+	}
+	kc := int(C.findKeyCode(C.UniChar(r), modifiers))
+	if kc < 0 {
+		return 0, false
+	}
+	return kc, true
+}
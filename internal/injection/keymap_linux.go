@@ -0,0 +1,177 @@
+//go:build linux
+
+package injection
+
+/*
+#cgo LDFLAGS: -lX11
+
+#include <stdlib.h>
+#include <X11/Xlib.h>
+#include <X11/XKBlib.h>
+
+typedef struct {
+    unsigned long keysym;
+    int keycode;
+    int level;
+    int rank; // 0 for the active group, 1 for the others
+} KeyEntry;
+
+static int dumpGroup(XkbDescPtr xkb, int group, int rank, KeyEntry *out, int n, int max) {
+    for (int kc = xkb->min_key_code; kc <= xkb->max_key_code; kc++) {
+        int groups = XkbKeyNumGroups(xkb, kc);
+        if (groups == 0) {
+            continue;
+        }
+        // Out of range groups wrap, as in the default XKB group behaviour
+        int g = group % groups;
+        int width = XkbKeyGroupWidth(xkb, kc, g);
+        for (int level = 0; level < width && n < max; level++) {
+            KeySym sym = XkbKeySymEntry(xkb, kc, level, g);
+            if (sym != NoSymbol) {
+                out[n].keysym = sym;
+                out[n].keycode = kc;
+                out[n].level = level;
+                out[n].rank = rank;
+                n++;
+            }
+        }
+    }
+    return n;
+}
+
+// Writes the keysyms of every key into out, the active layout group first.
+// Returns the number of entries, or -1 when there is no X display. The
+// keymap is fetched on every call so layout switches are picked up.
+int dumpKeymap(KeyEntry *out, int max) {
+    int major = XkbMajorVersion, minor = XkbMinorVersion, reason;
+    Display *dpy = XkbOpenDisplay(NULL, NULL, NULL, &major, &minor, &reason);
+    if (dpy == NULL) {
+        return -1;
+    }
+
+    int n = 0;
+    XkbDescPtr xkb = XkbGetMap(dpy, XkbKeyTypesMask | XkbKeySymsMask, XkbUseCoreKbd);
+    if (xkb != NULL) {
+        int active = 0;
+        XkbStateRec state;
+        if (XkbGetState(dpy, XkbUseCoreKbd, &state) == Success) {
+            active = state.group;
+        }
+        n = dumpGroup(xkb, active, 0, out, n, max);
+        for (int g = 0; g < XkbNumKbdGroups; g++) {
+            if (g != active) {
+                n = dumpGroup(xkb, g, 1, out, n, max);
+            }
+        }
+        XkbFreeKeyboard(xkb, 0, True);
+    }
+
+    XCloseDisplay(dpy);
+    return n;
+}
+*/
+import "C"
+import "unsafe"
+
+// maxKeymapEntries bounds the keymap dump: 248 keycodes, 4 groups, and a
+// generous number of levels.
+const maxKeymapEntries = 8192
+
+// keyStroke is a key and the shift level that selects the wanted symbol:
+// 0 plain, 1 Shift, 2 AltGr, 3 Shift+AltGr.
+type keyStroke struct {
+	code  int // evdev key code
+	level int
+}
+
+// keymap maps X keysyms to the key stroke producing them.
+type keymap map[uint32]keyStroke
+
+// loadKeymap reads the active XKB layout. It needs an X display, which
+// Wayland sessions provide through XWayland; ok is false when there is none.
+//
+// Synthetic keys are sent as evdev codes through uinput, and both X11 and
+// Wayland compositors translate those with the same keymap, so the codes
+// found here are also the right ones to send on Wayland.
+func loadKeymap() (km keymap, ok bool) {
+	entries := (*[maxKeymapEntries]C.KeyEntry)(C.malloc(C.size_t(maxKeymapEntries) * C.size_t(unsafe.Sizeof(C.KeyEntry{}))))
+	defer C.free(unsafe.Pointer(entries))
+
+	n := int(C.dumpKeymap(&entries[0], maxKeymapEntries))
+	if n < 0 {
+		return nil, false
+	}
+
+	km = make(keymap)
+	ranks := make(map[uint32]int)
+	for _, e := range entries[:n] {
+		sym := uint32(e.keysym)
+		// X keycodes are evdev codes offset by 8
+		ks := keyStroke{code: int(e.keycode) - 8, level: int(e.level)}
+		if ks.code <= 0 || ks.level > 3 {
+			continue
+		}
+		// Prefer the active group, then the lowest level
+		if prev, seen := km[sym]; seen && (ranks[sym] < int(e.rank) || (ranks[sym] == int(e.rank) && prev.level <= ks.level)) {
+			continue
+		}
+		km[sym] = ks
+		ranks[sym] = int(e.rank)
+	}
+	return km, true
+}
+
+// lookup returns the key stroke that types r.
+func (km keymap) lookup(r rune) (keyStroke, bool) {
+	ks, ok := km[keysymFor(r)]
+	return ks, ok
+}
+
+// layoutKeyCode returns the evdev code of the key that types r in the
+// active XKB layout. ok is false without an X display or when no key types
+// r.
+func layoutKeyCode(r rune, _ bool) (code int, ok bool) {
+	km, ok := loadKeymap()
+	if !ok {
+		return 0, false
+	}
+	ks, ok := km.lookup(r)
+	return ks.code, ok
+}
+
+// keysymFor returns the X keysym for r. Latin-1 characters share their
+// keysym values; everything else uses the Unicode keysym range.
+func keysymFor(r rune) uint32 {
+	if (r >= 0x20 && r <= 0x7e) || (r >= 0xa0 && r <= 0xff) {
+		return uint32(r)
+	}
+	return 0x01000000 | uint32(r)
+}
+
+// qwertyKeymap is the US QWERTY layout, used when the active layout cannot
+// be read.
+func qwertyKeymap() keymap {
+	km := make(keymap)
+	add := func(code int, plain, shifted rune) {
+		km[keysymFor(plain)] = keyStroke{code: code}
+		km[keysymFor(shifted)] = keyStroke{code: code, level: 1}
+	}
+	rows := []struct {
+		first          int // evdev code of the first key
+		plain, shifted string
+	}{
+		{2, "1234567890-=", "!@#$%^&*()_+"},
+		{16, "qwertyuiop[]", "QWERTYUIOP{}"},
+		{30, "asdfghjkl;'`", "ASDFGHJKL:\"~"},
+		{44, "zxcvbnm,./", "ZXCVBNM<>?"},
+	}
+	for _, row := range rows {
+		shifted := []rune(row.shifted)
+		for i, r := range []rune(row.plain) {
+			add(row.first+i, r, shifted[i])
+		}
+	}
+	add(43, '\\', '|')
+	km[keysymFor(' ')] = keyStroke{code: 57}
+	return km
+}
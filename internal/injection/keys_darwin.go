@@ -0,0 +1,11 @@
+//go:build darwin
+
+package injection
+
+import "github.com/micmonay/keybd_event"
+
+// pasteKeys are the keys a paste chord may end with. Mac keyboards have no
+// Insert key.
+var pasteKeys = map[string]pasteKey{
+	"v": {code: keybd_event.VK_V, char: 'v'},
+}
@@ -0,0 +1,11 @@
+//go:build linux
+
+package injection
+
+import "github.com/micmonay/keybd_event"
+
+// pasteKeys are the keys a paste chord may end with.
+var pasteKeys = map[string]pasteKey{
+	"v":      {code: keybd_event.VK_V, char: 'v'},
+	"insert": {code: keybd_event.VK_INSERT},
+}
@@ -0,0 +1,155 @@
+package injection
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cesp99/sussurro/internal/clipboard"
+)
+
+// chordKeyboard presses key chords: the uinput virtual keyboard on Linux,
+// keybd_event on macOS.
+type chordKeyboard interface {
+	// pressChord taps the key with the given code (already resolved
+	// through the keyboard layout) while holding the chord's modifiers.
+	pressChord(code int, keys chord) error
+}
+
+// pasteInjector copies the text to the clipboard and sends the paste
+// shortcut (Cmd+V on macOS, Ctrl+V elsewhere). Pasting is faster and more
+// reliable than typing for blocks of text with special characters.
+//
+// With a restore delay, the clipboard is snapshotted before the transcript
+// is written and put back once the target app has had time to paste.
+type pasteInjector struct {
+	kb           chordKeyboard
+	log          *slog.Logger
+	restoreDelay time.Duration // 0 leaves the transcript on the clipboard
+
+	mu      sync.Mutex
+	pending *pendingRestore
+
+	layoutWarning sync.Once
+}
+
+// pendingRestore is a scheduled restore of the user's clipboard.
+type pendingRestore struct {
+	snap  *clipboard.Snapshot
+	text  string // the transcript we wrote over it
+	timer *time.Timer
+}
+
+func newPasteInjector(restoreDelay time.Duration, log *slog.Logger) (*pasteInjector, error) {
+	kb, err := newChordKeyboard()
+	if err != nil {
+		return nil, err
+	}
+	return &pasteInjector{kb: kb, log: log, restoreDelay: restoreDelay}, nil
+}
+
+func (i *pasteInjector) Method() string { return MethodPaste }
+
+// Inject writes text to the clipboard and pastes it with the platform
+// paste shortcut.
+func (i *pasteInjector) Inject(text string) error {
+	return i.injectWith(text, defaultPasteChord())
+}
+
+// injectWith writes text to the clipboard and pastes it with keys.
+func (i *pasteInjector) injectWith(text string, keys chord) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	snap := i.takeSnapshot()
+
+	if err := clipboard.Write(text); err != nil {
+		if snap != nil {
+			snap.Release()
+		}
+		return err
+	}
+
+	// Add a small delay to ensure clipboard is ready and window is focused
+	time.Sleep(100 * time.Millisecond)
+
+	err := i.paste(keys)
+
+	if snap != nil {
+		p := &pendingRestore{snap: snap, text: text}
+		p.timer = time.AfterFunc(i.restoreDelay, func() { i.restore(p) })
+		i.pending = p
+	}
+	return err
+}
+
+// takeSnapshot returns the clipboard contents to restore after this paste,
+// or nil when restoring is disabled. If the previous paste has not been
+// restored yet, its snapshot (the user's original clipboard) is reused.
+// Must be called with i.mu held.
+func (i *pasteInjector) takeSnapshot() *clipboard.Snapshot {
+	if i.restoreDelay <= 0 {
+		return nil
+	}
+
+	if p := i.pending; p != nil {
+		i.pending = nil
+		p.timer.Stop()
+		if clipboardHolds(p.text) {
+			return p.snap
+		}
+		p.snap.Release()
+	}
+
+	snap, err := clipboard.Save()
+	if err != nil {
+		i.log.Warn("Failed to save clipboard, it will not be restored", "error", err)
+		return nil
+	}
+	return snap
+}
+
+// restore puts the user's clipboard back unless something new was copied
+// since the paste.
+func (i *pasteInjector) restore(p *pendingRestore) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.pending != p {
+		return // superseded by a newer paste
+	}
+	i.pending = nil
+	defer p.snap.Release()
+
+	if !clipboardHolds(p.text) {
+		i.log.Debug("Clipboard changed since paste, not restoring")
+		return
+	}
+	if err := p.snap.Restore(); err != nil {
+		i.log.Warn("Failed to restore clipboard", "error", err)
+		return
+	}
+	i.log.Debug("Restored previous clipboard contents")
+}
+
+// clipboardHolds reports whether the clipboard still contains text.
+func clipboardHolds(text string) bool {
+	current, err := clipboard.Read()
+	return err == nil && current == text
+}
+
+// paste presses and releases the paste chord.
+func (i *pasteInjector) paste(keys chord) error {
+	code, resolved := keys.keyCode()
+	if !resolved {
+		i.layoutWarning.Do(func() {
+			i.log.Warn("Could not look up the paste key in the keyboard layout, assuming QWERTY", "keys", keys)
+		})
+	}
+
+	if err := i.kb.pressChord(code, keys); err != nil {
+		return fmt.Errorf("failed to simulate paste (%s): %w", keys, err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package injection
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/cesp99/sussurro/internal/config"
+	ctxProvider "github.com/cesp99/sussurro/internal/context"
+)
+
+// terminalRules are built in on Linux, where terminals either ignore Ctrl+V
+// or insert a literal ^V. They only fix pasting, so they apply when the
+// default method is paste. They are matched after the user's own rules, so
+// a rule in the config for the same app overrides them. Values are WM_CLASS
+// class names as reported by the context provider.
+var terminalRules = []config.AppRule{
+	{
+		Match:     ctxProvider.TerminalApps,
+		PasteKeys: "ctrl+shift+v",
+	},
+	{
+		// Shift+Insert pastes the primary selection here, not the clipboard
+		Match:  ctxProvider.XTermApps,
+		Method: MethodType,
+	},
+}
+
+// appRule is a compiled config.AppRule.
+type appRule struct {
+	match     map[string]bool // lower-case app names
+	out       output          // nil keeps the default method
+	pasteKeys *chord          // nil keeps the default chord
+	preDelay  time.Duration
+	postDelay time.Duration
+}
+
+// router implements Injector by picking the rule for the target app.
+type router struct {
+	def   output
+	rules []appRule
+	log   *slog.Logger
+}
+
+func (r *router) Method() string { return r.def.Method() }
+
+func (r *router) MethodFor(target *ctxProvider.ContextInfo) string {
+	return r.outputFor(r.ruleFor(target)).Method()
+}
+
+// Inject delivers text using the first rule matching target, or the default
+// method when none does.
+func (r *router) Inject(text string, target *ctxProvider.ContextInfo) error {
+	rule := r.ruleFor(target)
+	if rule == nil {
+		return r.def.Inject(text)
+	}
+
+	out := r.outputFor(rule)
+	r.log.Debug("Applying app injection rule", "app", target.AppName, "method", out.Method())
+
+	if rule.preDelay > 0 {
+		time.Sleep(rule.preDelay)
+	}
+	var err error
+	if p, ok := out.(*pasteInjector); ok && rule.pasteKeys != nil {
+		err = p.injectWith(text, *rule.pasteKeys)
+	} else {
+		err = out.Inject(text)
+	}
+	if rule.postDelay > 0 {
+		time.Sleep(rule.postDelay)
+	}
+	return err
+}
+
+// outputFor returns the method rule selects; rule may be nil.
+func (r *router) outputFor(rule *appRule) output {
+	if rule == nil || rule.out == nil {
+		return r.def
+	}
+	return rule.out
+}
+
+func (r *router) ruleFor(target *ctxProvider.ContextInfo) *appRule {
+	if target == nil || target.AppName == "" {
+		return nil
+	}
+	app := strings.ToLower(target.AppName)
+	for i := range r.rules {
+		if r.rules[i].match[app] {
+			return &r.rules[i]
+		}
+	}
+	return nil
+}
+
+// newRouter compiles the user's rules followed by the built-in ones. Every
+// method a rule needs is created up front; newOutput is shared so each
+// method has a single instance (and a single clipboard restore queue).
+// Built-in rules whose method is unavailable are dropped with a warning.
+func newRouter(def output, cfg config.InjectionConfig, newOutput func(string) (output, error), log *slog.Logger) (*router, error) {
+	r := &router{def: def, log: log}
+
+	for i, rule := range cfg.Apps {
+		compiled, err := compileRule(rule, newOutput)
+		if err != nil {
+			return nil, fmt.Errorf("injection.apps[%d]: %w", i, err)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+
+	if runtime.GOOS == "linux" && def.Method() == MethodPaste {
+		for _, rule := range terminalRules {
+			compiled, err := compileRule(rule, newOutput)
+			if err != nil {
+				log.Warn("Skipping built-in terminal injection rule", "apps", rule.Match, "error", err)
+				continue
+			}
+			r.rules = append(r.rules, compiled)
+		}
+	}
+	return r, nil
+}
+
+func compileRule(rule config.AppRule, newOutput func(string) (output, error)) (appRule, error) {
+	compiled := appRule{match: make(map[string]bool)}
+	for _, app := range rule.Match {
+		compiled.match[strings.ToLower(strings.TrimSpace(app))] = true
+	}
+	if len(compiled.match) == 0 {
+		return appRule{}, fmt.Errorf("rule has no apps to match")
+	}
+
+	if rule.Method != "" {
+		m, err := ParseMethod(rule.Method)
+		if err != nil {
+			return appRule{}, err
+		}
+		if compiled.out, err = newOutput(m); err != nil {
+			return appRule{}, err
+		}
+	}
+
+	if rule.PasteKeys != "" {
+		keys, err := parseChord(rule.PasteKeys)
+		if err != nil {
+			return appRule{}, err
+		}
+		compiled.pasteKeys = &keys
+	}
+
+	var err error
+	if compiled.preDelay, err = parseDelay("pre_delay", rule.PreDelay); err != nil {
+		return appRule{}, err
+	}
+	if compiled.postDelay, err = parseDelay("post_delay", rule.PostDelay); err != nil {
+		return appRule{}, err
+	}
+	return compiled, nil
+}
+
+func parseDelay(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return d, nil
+}
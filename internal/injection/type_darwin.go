@@ -0,0 +1,61 @@
+//go:build darwin
+
+package injection
+
+/*
+#cgo LDFLAGS: -framework ApplicationServices
+
+#include <ApplicationServices/ApplicationServices.h>
+
+// Posts a key down/up pair carrying a UTF-16 string. The virtual key code is
+// ignored by applications when a Unicode string is attached.
+static void typeUnicode(const UniChar *chars, int length) {
+    CGEventRef down = CGEventCreateKeyboardEvent(NULL, 0, true);
+    CGEventRef up = CGEventCreateKeyboardEvent(NULL, 0, false);
+    CGEventKeyboardSetUnicodeString(down, length, chars);
+    CGEventKeyboardSetUnicodeString(up, length, chars);
+    CGEventPost(kCGHIDEventTap, down);
+    CGEventPost(kCGHIDEventTap, up);
+    CFRelease(down);
+    CFRelease(up);
+}
+*/
+import "C"
+import (
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// maxUnicodeChunk is the longest string macOS reliably accepts per event.
+const maxUnicodeChunk = 20
+
+// typeInjector types text with CGEvents carrying Unicode strings, so any
+// character can be entered regardless of the keyboard layout.
+type typeInjector struct{}
+
+// newTypeInjector ignores keyboard; macOS needs no virtual keyboard.
+func newTypeInjector(_ string, _ *slog.Logger) (output, error) {
+	return &typeInjector{}, nil
+}
+
+func (i *typeInjector) Method() string { return MethodType }
+
+// Inject types text into the focused application.
+func (i *typeInjector) Inject(text string) error {
+	// Return submits more reliably than a literal line feed
+	units := utf16.Encode([]rune(strings.ReplaceAll(text, "\n", "\r")))
+	for len(units) > 0 {
+		n := min(len(units), maxUnicodeChunk)
+		// Never split a surrogate pair across events
+		if n < len(units) && utf16.IsSurrogate(rune(units[n-1])) && units[n-1] < 0xDC00 {
+			n--
+		}
+		C.typeUnicode((*C.UniChar)(unsafe.Pointer(&units[0])), C.int(n))
+		units = units[n:]
+		time.Sleep(5 * time.Millisecond)
+	}
+	return nil
+}
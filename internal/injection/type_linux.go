@@ -0,0 +1,118 @@
+//go:build linux
+
+package injection
+
+import (
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// newTypeInjector picks how the type method synthesizes keystrokes.
+// KeyboardAuto prefers the uinput virtual keyboard on Wayland, where it is
+// the only thing that reaches every client, and xdotool on X11, where
+// xdotool handles any character without the Unicode fallback.
+func newTypeInjector(keyboard string, log *slog.Logger) (output, error) {
+	switch keyboard {
+	case KeyboardUinput:
+		return newUinputTypeInjector(log)
+	case KeyboardTools:
+		return newToolTypeInjector()
+	}
+
+	first, second := newToolTypeInjector, func() (output, error) { return newUinputTypeInjector(log) }
+	if isWayland() {
+		first, second = second, first
+	}
+	out, err := first()
+	if err == nil {
+		return out, nil
+	}
+	log.Debug("Preferred type backend unavailable", "error", err)
+	out, err2 := second()
+	if err2 != nil {
+		return nil, fmt.Errorf("%w; %w", err, err2)
+	}
+	return out, nil
+}
+
+// uinputTypeInjector types through the uinput virtual keyboard, mapping each
+// character to a key through the active keyboard layout.
+type uinputTypeInjector struct {
+	kb  *uinputKeyboard
+	log *slog.Logger
+
+	layoutWarning sync.Once
+}
+
+func newUinputTypeInjector(log *slog.Logger) (output, error) {
+	kb, err := virtualKeyboard()
+	if err != nil {
+		return nil, err
+	}
+	return &uinputTypeInjector{kb: kb, log: log}, nil
+}
+
+func (i *uinputTypeInjector) Method() string { return MethodType }
+
+// Inject types text into the focused window. The layout is read on every
+// call so switching layouts takes effect immediately.
+func (i *uinputTypeInjector) Inject(text string) error {
+	km, ok := loadKeymap()
+	if !ok {
+		i.layoutWarning.Do(func() {
+			i.log.Warn("Could not read the keyboard layout, typing as US QWERTY")
+		})
+		km = qwertyKeymap()
+	}
+	return i.kb.typeText(text, km)
+}
+
+// toolTypeInjector types the text with an external tool: xdotool on X11,
+// and wtype (or ydotool as a fallback) on Wayland. xdotool and wtype map
+// arbitrary Unicode characters to keysyms, so non-ASCII text and non-QWERTY
+// layouts work. ydotool sends US QWERTY key positions.
+type toolTypeInjector struct {
+	argv []string // command reading the text on stdin
+}
+
+func newToolTypeInjector() (output, error) {
+	var candidates [][]string
+	if isWayland() {
+		candidates = [][]string{
+			{"wtype", "-"},
+			{"ydotool", "type", "--file", "-"},
+		}
+	} else {
+		candidates = [][]string{
+			{"xdotool", "type", "--clearmodifiers", "--delay", "5", "--file", "-"},
+		}
+	}
+
+	var names []string
+	for _, argv := range candidates {
+		if _, err := exec.LookPath(argv[0]); err == nil {
+			return &toolTypeInjector{argv: argv}, nil
+		}
+		names = append(names, argv[0])
+	}
+	return nil, fmt.Errorf("injection method \"type\" needs %s installed", strings.Join(names, " or "))
+}
+
+func (i *toolTypeInjector) Method() string { return MethodType }
+
+// Inject types text into the focused window.
+func (i *toolTypeInjector) Inject(text string) error {
+	cmd := exec.Command(i.argv[0], i.argv[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			return fmt.Errorf("%s failed: %w", i.argv[0], err)
+		}
+		return fmt.Errorf("%s failed: %w: %s", i.argv[0], err, msg)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+//go:build linux
+
+package injection
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Event types and key codes from linux/input-event-codes.h.
+const (
+	evSyn     = 0x00
+	evKey     = 0x01
+	synReport = 0
+
+	keyTab       = 15
+	keyU         = 22
+	keyEnter     = 28
+	keyLeftCtrl  = 29
+	keyLeftShift = 42
+	keyLeftAlt   = 56
+	keySpace     = 57
+	keyRightAlt  = 100 // AltGr
+	keyLeftMeta  = 125
+	keyMaxCode   = 248
+)
+
+// ioctls from linux/uinput.h.
+const (
+	uiSetEvBit  = 0x40045564 // _IOW('U', 100, int)
+	uiSetKeyBit = 0x40045565 // _IOW('U', 101, int)
+	uiDevSetup  = 0x405c5503 // _IOW('U', 3, struct uinput_setup)
+	uiDevCreate = 0x5501     // _IO('U', 1)
+)
+
+const (
+	uinputName = "Sussurro virtual keyboard"
+
+	// uinputSettle is how long compositors need to pick up a new input
+	// device; keys sent earlier are dropped.
+	uinputSettle = time.Second
+
+	// uinputKeyDelay spaces out typed characters so clients keep up.
+	uinputKeyDelay = 2 * time.Millisecond
+)
+
+// uinputKeyboard is a virtual keyboard created through /dev/uinput. The
+// kernel presents it to the compositor like a physical keyboard, so its keys
+// reach native Wayland clients as well as X11 ones.
+type uinputKeyboard struct {
+	mu    sync.Mutex
+	w     io.Writer // the uinput device, or a fake in tests
+	ready time.Time
+}
+
+// newUinputKeyboard sends input events to w, which must accept one or more
+// whole struct input_event records per write.
+func newUinputKeyboard(w io.Writer, settle time.Duration) *uinputKeyboard {
+	return &uinputKeyboard{w: w, ready: time.Now().Add(settle)}
+}
+
+// sharedKeyboard is created once and shared by the paste and type methods.
+var sharedKeyboard struct {
+	once sync.Once
+	kb   *uinputKeyboard
+	err  error
+}
+
+// virtualKeyboard returns the process-wide uinput keyboard, creating the
+// device on first use. The device lives until the process exits.
+func virtualKeyboard() (*uinputKeyboard, error) {
+	sharedKeyboard.once.Do(func() {
+		f, err := openUinput()
+		if err != nil {
+			sharedKeyboard.err = err
+			return
+		}
+		sharedKeyboard.kb = newUinputKeyboard(f, uinputSettle)
+	})
+	return sharedKeyboard.kb, sharedKeyboard.err
+}
+
+func newChordKeyboard() (chordKeyboard, error) {
+	kb, err := virtualKeyboard()
+	if err != nil {
+		return nil, err
+	}
+	return kb, nil
+}
+
+// openUinput creates the virtual keyboard device.
+func openUinput() (*os.File, error) {
+	path := ""
+	for _, p := range []string{"/dev/uinput", "/dev/input/uinput"} {
+		if _, err := os.Stat(p); err == nil {
+			path = p
+			break
+		}
+	}
+	if path == "" {
+		return nil, errors.New("no uinput device: load the module with 'sudo modprobe uinput' " +
+			"and add 'uinput' to /etc/modules-load.d/uinput.conf to load it at boot")
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return nil, uinputPermissionError(path)
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	if err := setupUinput(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to create virtual keyboard on %s: %w", path, err)
+	}
+	return f, nil
+}
+
+func setupUinput(f *os.File) error {
+	if err := ioctl(f, uiSetEvBit, evKey); err != nil {
+		return err
+	}
+	if err := ioctl(f, uiSetEvBit, evSyn); err != nil {
+		return err
+	}
+	for code := uintptr(1); code < keyMaxCode; code++ {
+		if err := ioctl(f, uiSetKeyBit, code); err != nil {
+			return err
+		}
+	}
+
+	// struct uinput_setup: struct input_id, char name[80], __u32 ff_effects_max
+	var setup [92]byte
+	binary.NativeEndian.PutUint16(setup[0:], 0x06) // BUS_VIRTUAL
+	binary.NativeEndian.PutUint16(setup[2:], 0x1)  // vendor
+	binary.NativeEndian.PutUint16(setup[4:], 0x1)  // product
+	binary.NativeEndian.PutUint16(setup[6:], 0x1)  // version
+	copy(setup[8:87], uinputName)
+	if err := ioctl(f, uiDevSetup, uintptr(unsafe.Pointer(&setup[0]))); err != nil {
+		return err
+	}
+	return ioctl(f, uiDevCreate, 0)
+}
+
+func ioctl(f *os.File, req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// uinputPermissionError explains how to let the user create input devices.
+func uinputPermissionError(path string) error {
+	hint := ""
+	var st syscall.Stat_t
+	if syscall.Stat(path, &st) == nil {
+		group := strconv.Itoa(int(st.Gid))
+		if g, err := user.LookupGroupId(group); err == nil {
+			group = g.Name
+		}
+		if gids, err := os.Getgroups(); err == nil && !slices.Contains(gids, int(st.Gid)) {
+			hint = fmt.Sprintf(" (the device belongs to group %q, which this session is not in)", group)
+		}
+	}
+	return fmt.Errorf("permission denied opening %s%s. Allow virtual keyboards with a udev rule, "+
+		"e.g. KERNEL==\"uinput\", GROUP=\"input\", MODE=\"0660\", OPTIONS+=\"static_node=uinput\" "+
+		"in /etc/udev/rules.d/99-uinput.rules, then run 'sudo usermod -aG input $USER' and log in again", path, hint)
+}
+
+// pressChord taps code with the chord's modifiers held.
+func (k *uinputKeyboard) pressChord(code int, c chord) error {
+	var mods []int
+	if c.ctrl {
+		mods = append(mods, keyLeftCtrl)
+	}
+	if c.shift {
+		mods = append(mods, keyLeftShift)
+	}
+	if c.alt {
+		mods = append(mods, keyLeftAlt)
+	}
+	if c.super {
+		mods = append(mods, keyLeftMeta)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.waitReady()
+	return k.tap(code, mods...)
+}
+
+// typeText types text using km to find the key for each character.
+// Characters the layout cannot produce are entered as Ctrl+Shift+U Unicode
+// sequences, which GTK and IBus-based apps understand.
+func (k *uinputKeyboard) typeText(text string, km keymap) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.waitReady()
+
+	for _, r := range text {
+		var err error
+		switch r {
+		case '\n':
+			err = k.tap(keyEnter)
+		case '\t':
+			err = k.tap(keyTab)
+		case '\r':
+			continue
+		default:
+			if ks, ok := km.lookup(r); ok {
+				err = k.tap(ks.code, levelModifiers(ks.level)...)
+			} else {
+				err = k.typeUnicode(r, km)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		time.Sleep(uinputKeyDelay)
+	}
+	return nil
+}
+
+// typeUnicode enters r as Ctrl+Shift+U, its hex code point, and Space.
+func (k *uinputKeyboard) typeUnicode(r rune, km keymap) error {
+	u := keyStroke{code: keyU}
+	if ks, ok := km.lookup('u'); ok {
+		u = ks
+	}
+	if err := k.tap(u.code, keyLeftCtrl, keyLeftShift); err != nil {
+		return err
+	}
+	for _, digit := range strconv.FormatInt(int64(r), 16) {
+		ks, ok := km.lookup(digit)
+		if !ok {
+			return fmt.Errorf("cannot type %q: the keyboard layout has no key for %q", r, digit)
+		}
+		if err := k.tap(ks.code, levelModifiers(ks.level)...); err != nil {
+			return err
+		}
+	}
+	return k.tap(keySpace)
+}
+
+// levelModifiers returns the keys selecting a shift level.
+func levelModifiers(level int) []int {
+	var mods []int
+	if level&1 != 0 {
+		mods = append(mods, keyLeftShift)
+	}
+	if level&2 != 0 {
+		mods = append(mods, keyRightAlt)
+	}
+	return mods
+}
+
+// tap presses mods, taps code, and releases mods in reverse order.
+func (k *uinputKeyboard) tap(code int, mods ...int) error {
+	var err error
+	pressed := 0
+	for _, m := range mods {
+		if err = k.key(m, true); err != nil {
+			break
+		}
+		pressed++
+	}
+	if err == nil {
+		if err = k.key(code, true); err == nil {
+			err = k.key(code, false)
+		}
+	}
+	for i := pressed - 1; i >= 0; i-- {
+		// Always release, so a failed write does not leave a modifier stuck
+		if upErr := k.key(mods[i], false); err == nil {
+			err = upErr
+		}
+	}
+	return err
+}
+
+// key writes a key press or release followed by a sync report.
+func (k *uinputKeyboard) key(code int, down bool) error {
+	value := int32(0)
+	if down {
+		value = 1
+	}
+	buf := appendInputEvent(nil, evKey, uint16(code), value)
+	buf = appendInputEvent(buf, evSyn, synReport, 0)
+	if _, err := k.w.Write(buf); err != nil {
+		return fmt.Errorf("failed to write key event: %w", err)
+	}
+	return nil
+}
+
+// waitReady blocks until the compositor has had time to add the device.
+func (k *uinputKeyboard) waitReady() {
+	if d := time.Until(k.ready); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// appendInputEvent encodes a struct input_event. The timestamp is left zero;
+// the kernel fills it in.
+func appendInputEvent(buf []byte, typ, code uint16, value int32) []byte {
+	buf = append(buf, make([]byte, unsafe.Sizeof(syscall.Timeval{}))...)
+	buf = binary.NativeEndian.AppendUint16(buf, typ)
+	buf = binary.NativeEndian.AppendUint16(buf, code)
+	return binary.NativeEndian.AppendUint32(buf, uint32(value))
+}
@@ -0,0 +1,111 @@
+//go:build linux
+
+package injection
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// keyEvent is a decoded struct input_event without its timestamp.
+type keyEvent struct {
+	typ, code uint16
+	value     int32
+}
+
+// decodeEvents splits buf into input_event records.
+func decodeEvents(t *testing.T, buf []byte) []keyEvent {
+	t.Helper()
+	tv := int(unsafe.Sizeof(syscall.Timeval{}))
+	size := tv + 8
+	if len(buf)%size != 0 {
+		t.Fatalf("%d bytes is not a whole number of %d-byte input events", len(buf), size)
+	}
+	var events []keyEvent
+	for ; len(buf) > 0; buf = buf[size:] {
+		events = append(events, keyEvent{
+			typ:   binary.NativeEndian.Uint16(buf[tv:]),
+			code:  binary.NativeEndian.Uint16(buf[tv+2:]),
+			value: int32(binary.NativeEndian.Uint32(buf[tv+4:])),
+		})
+	}
+	return events
+}
+
+// keys returns the key events as +code for a press and -code for a release,
+// checking that each one is followed by a sync report.
+func keys(t *testing.T, events []keyEvent) []int {
+	t.Helper()
+	var out []int
+	for i := 0; i < len(events); i += 2 {
+		ev := events[i]
+		if ev.typ != evKey {
+			t.Fatalf("event %d: got type %d, want EV_KEY", i, ev.typ)
+		}
+		if i+1 >= len(events) || events[i+1] != (keyEvent{evSyn, synReport, 0}) {
+			t.Fatalf("event %d: key %d is not followed by SYN_REPORT", i, ev.code)
+		}
+		switch ev.value {
+		case 1:
+			out = append(out, int(ev.code))
+		case 0:
+			out = append(out, -int(ev.code))
+		default:
+			t.Fatalf("event %d: unexpected value %d for key %d", i, ev.value, ev.code)
+		}
+	}
+	return out
+}
+
+func TestUinputPasteChord(t *testing.T) {
+	var buf bytes.Buffer
+	kb := newUinputKeyboard(&buf, 0)
+
+	c, err := parseChord("ctrl+shift+v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.pressChord(c.key.code, c); err != nil {
+		t.Fatal(err)
+	}
+
+	const keyV = 47
+	want := []int{keyLeftCtrl, keyLeftShift, keyV, -keyV, -keyLeftShift, -keyLeftCtrl}
+	if got := keys(t, decodeEvents(t, buf.Bytes())); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestUinputTypeText(t *testing.T) {
+	const (
+		keyE = 18
+		key4 = 5
+		keyA = 30
+		keyB = 48
+	)
+	km := qwertyKeymap()
+	km[keysymFor('€')] = keyStroke{code: keyE, level: 2} // AltGr+E, as on German layouts
+
+	var buf bytes.Buffer
+	kb := newUinputKeyboard(&buf, 0)
+	// 'ä' (U+00E4) is not in the keymap and falls back to Ctrl+Shift+U e4 Space
+	if err := kb.typeText("aB€ä\n", km); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{
+		keyA, -keyA,
+		keyLeftShift, keyB, -keyB, -keyLeftShift,
+		keyRightAlt, keyE, -keyE, -keyRightAlt,
+		keyLeftCtrl, keyLeftShift, keyU, -keyU, -keyLeftShift, -keyLeftCtrl,
+		keyE, -keyE, key4, -key4, keySpace, -keySpace,
+		keyEnter, -keyEnter,
+	}
+	if got := keys(t, decodeEvents(t, buf.Bytes())); !slices.Equal(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
@@ -1,12 +1,14 @@
 package llm
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 
 	llama "github.com/AshkanYarmoradi/go-llama.cpp"
+	ctxProvider "github.com/cesp99/sussurro/internal/context"
 	"github.com/cesp99/sussurro/internal/logger"
 )
 
@@ -45,11 +47,18 @@ func NewEngine(modelPath string, threads int, contextSize int, gpuLayers int, de
 	}, nil
 }
 
-// CleanupText processes the raw transcription to remove artifacts and fix grammar
-func (e *Engine) CleanupText(rawText string) (string, error) {
-	// Qwen 3 Sussurro Chat template (ChatML)
-	prompt := fmt.Sprintf(`<|im_start|>system
-You are a text cleanup tool for speech-to-text transcriptions. Your ONLY job is to clean up the transcription below.
+// TargetContext describes where the cleaned text is going to be inserted.
+// It is rendered into the system prompt so the cleanup can match the style of
+// the target application.
+type TargetContext struct {
+	AppName         string
+	WindowTitle     string
+	SurroundingText string // optional; text around the cursor, if known
+}
+
+// systemPrompt is the prompt the Qwen 3 Sussurro model was fine-tuned on.
+// It must not change when no TargetContext is supplied.
+const systemPrompt = `You are a text cleanup tool for speech-to-text transcriptions. Your ONLY job is to clean up the transcription below.
 
 RULES:
 1. Remove filler words: um, uh, ah, like, you know, I mean, sort of, kind of, basically, actually, literally
@@ -67,12 +76,26 @@ DO NOT:
 - Use <think> tags or any other tags
 - Add preamble like "Here is..." or "The corrected text is..."
 
-Output ONLY the cleaned transcription text, nothing else.
-/nothink<|im_end|>
-<|im_start|>user
-%s<|im_end|>
-<|im_start|>assistant
-`, rawText)
+`
+
+// CleanupText processes the raw transcription to remove artifacts and fix grammar
+func (e *Engine) CleanupText(rawText string) (string, error) {
+	return e.CleanupTextWithContext(context.Background(), rawText, nil)
+}
+
+// CleanupTextWithContext is like CleanupText but also tells the model which
+// application the text is destined for, and stops generating when ctx is
+// cancelled. A nil target produces exactly the same prompt as CleanupText.
+func (e *Engine) CleanupTextWithContext(ctx context.Context, rawText string, target *TargetContext) (string, error) {
+	// Qwen 3 Sussurro Chat template (ChatML)
+	prompt := "<|im_start|>system\n" + systemPrompt + renderTarget(target) +
+		"Output ONLY the cleaned transcription text, nothing else.\n/nothink<|im_end|>\n" +
+		"<|im_start|>user\n" + rawText + "<|im_end|>\n" +
+		"<|im_start|>assistant\n"
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 
 	// We use Predict with strict options
 	var cleaned string
@@ -89,8 +112,13 @@ Output ONLY the cleaned transcription text, nothing else.
 		llama.SetTemperature(0.1), // Low temperature for deterministic output
 		llama.SetTopP(0.9),
 		llama.SetStopWords("<|im_end|>"),
+		// Returning false from the token callback stops generation
+		llama.SetTokenCallback(func(string) bool { return ctx.Err() == nil }),
 	)
 
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
 	if err != nil {
 		return "", fmt.Errorf("prediction failed: %w", err)
 	}
@@ -134,6 +162,95 @@ Output ONLY the cleaned transcription text, nothing else.
 	return cleaned, nil
 }
 
+// renderTarget formats the target context as an extra prompt section.
+// Returns "" when there is nothing useful to say about the target.
+func renderTarget(target *TargetContext) string {
+	if target == nil {
+		return ""
+	}
+	app := sanitizePromptField(target.AppName, 80)
+	title := sanitizePromptField(target.WindowTitle, 120)
+	if app == "unknown" {
+		app = ""
+	}
+	if title == "unknown" {
+		title = ""
+	}
+	if app == "" && title == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("TARGET APPLICATION:\n")
+	if app != "" {
+		fmt.Fprintf(&b, "- Application: %s\n", app)
+	}
+	if title != "" {
+		fmt.Fprintf(&b, "- Window: %s\n", title)
+	}
+	if hint := styleHint(app); hint != "" {
+		fmt.Fprintf(&b, "- Style: %s\n", hint)
+	}
+	if text := sanitizePromptField(target.SurroundingText, 400); text != "" {
+		fmt.Fprintf(&b, "- Text near the cursor (for reference only, do not repeat it): %s\n", text)
+	}
+	b.WriteString("Adapt punctuation and tone to the target, but the RULES above always apply.\n\n")
+	return b.String()
+}
+
+// styleHint returns a short style instruction for well-known application
+// families, matched on the lowercased application name / WM_CLASS.
+func styleHint(app string) string {
+	name := strings.ToLower(app)
+	for _, family := range styleFamilies {
+		for _, match := range family.apps {
+			if strings.Contains(name, match) {
+				return family.hint
+			}
+		}
+	}
+	return ""
+}
+
+// terminalApps adds the generic names that catch macOS terminals and
+// unlisted Linux ones to the WM_CLASS names injection uses.
+var terminalApps = append(append([]string{"terminal", "iterm", "wezterm"},
+	ctxProvider.TerminalApps...), ctxProvider.XTermApps...)
+
+var styleFamilies = []struct {
+	apps []string
+	hint string
+}{
+	{
+		apps: terminalApps,
+		hint: "terminal; keep commands, flags, file paths and code identifiers exactly as spoken, no trailing period",
+	},
+	{
+		apps: []string{"code", "jetbrains", "idea", "pycharm", "goland", "sublime", "zed", "vim", "emacs", "kate", "xcode"},
+		hint: "code editor; write identifiers in code style (camelCase, snake_case) when they are clearly code",
+	},
+	{
+		apps: []string{"slack", "discord", "telegram", "signal", "element", "teams", "whatsapp", "messages", "mattermost"},
+		hint: "chat message; keep it short and conversational",
+	},
+	{
+		apps: []string{"thunderbird", "mail", "outlook", "evolution", "geary", "mailspring", "spark"},
+		hint: "email; use complete sentences and a formal tone",
+	},
+}
+
+// sanitizePromptField flattens s to a single line, strips ChatML control
+// tokens, and truncates it to at most maxRunes runes.
+func sanitizePromptField(s string, maxRunes int) string {
+	s = strings.ReplaceAll(s, "<|", "")
+	s = strings.ReplaceAll(s, "|>", "")
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > maxRunes {
+		s = string(runes[:maxRunes]) + "…"
+	}
+	return s
+}
+
 // fixPunctuationSpacing ensures proper spacing after punctuation marks
 func fixPunctuationSpacing(text string) string {
 	// Add space after period when:
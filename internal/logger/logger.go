@@ -1,11 +1,17 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
 
 func Init(level string) *slog.Logger {
+	return InitWithOutput(level, os.Stdout)
+}
+
+// InitWithOutput is Init with logs written to w instead of stdout.
+func InitWithOutput(level string, w io.Writer) *slog.Logger {
 	var lvl slog.Level
 	switch level {
 	case "debug":
@@ -24,7 +30,7 @@ func Init(level string) *slog.Logger {
 		Level: lvl,
 	}
 
-	handler := slog.NewTextHandler(os.Stdout, opts)
+	handler := slog.NewTextHandler(w, opts)
 	logger := slog.New(handler)
 	slog.SetDefault(logger)
 
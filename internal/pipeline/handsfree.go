@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"slices"
+	"time"
+
+	"github.com/cesp99/sussurro/internal/audio"
+	ctxProvider "github.com/cesp99/sussurro/internal/context"
+)
+
+// defaultHandsFreePause is the silence that ends an utterance in hands-free
+// dictation when handsfree.pause is not set.
+const defaultHandsFreePause = 800 * time.Millisecond
+
+// handsFree is the endpointing state of hands-free dictation. The VAD runs
+// over everything captured; an utterance starts at its onset, together with
+// the audio just before it, and is queued for processing once the speaker
+// pauses.
+type handsFree struct {
+	vad       *audio.VAD
+	lead      *audio.RingBuffer               // audio before the utterance, so its onset is not cut off
+	utterance []float32                       // nil between utterances
+	target    <-chan *ctxProvider.ContextInfo // window focused when the utterance started
+	skipping  bool                            // the utterance was cancelled; wait for the next one
+}
+
+// discard drops the utterance being spoken, if any.
+func (h *handsFree) discard() bool {
+	if h.utterance == nil {
+		return false
+	}
+	h.utterance = nil
+	h.target = nil
+	h.skipping = true
+	return true
+}
+
+// SetHandsFreePause sets how long a pause ends an utterance in hands-free
+// dictation. value is a duration string; "" means the default of 800ms.
+// Must be called before Start().
+func (p *Pipeline) SetHandsFreePause(value string) {
+	p.handsFreePause = p.parseDuration("pause", value, defaultHandsFreePause)
+}
+
+// SetHandsFree turns hands-free dictation on or off. While it is on the
+// microphone is listened to continuously, and every utterance, ended by a
+// pause, is transcribed, cleaned up, and injected in the order it was
+// spoken. Turning it off still processes the utterance in progress. Returns
+// false if it cannot be turned on because a recording is in progress.
+func (p *Pipeline) SetHandsFree(on bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if on == (p.handsFree != nil) {
+		return true
+	}
+
+	if on {
+		if p.isRecording {
+			return false
+		}
+		vadParams := p.vadParams
+		vadParams.Hangover = p.handsFreePause
+		lead := vadParams.MinSpeech + vadParams.Padding
+		p.handsFree = &handsFree{
+			vad:  audio.NewVAD(vadParams),
+			lead: audio.NewRingBuffer(int(lead.Seconds() * float64(vadParams.SampleRate))),
+		}
+		p.log.Debug("Hands-free dictation on", "pause", p.handsFreePause)
+	} else {
+		p.flushUtteranceLocked()
+		p.handsFree = nil
+		p.preRoll.Reset()
+		p.log.Debug("Hands-free dictation off")
+	}
+	p.notifyState(p.stateLocked())
+	return true
+}
+
+// HandsFree reports whether hands-free dictation is on.
+func (p *Pipeline) HandsFree() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.handsFree != nil
+}
+
+// listenLocked runs hands-free endpointing over the next chunk of audio.
+// Utterances longer than maxSamples are cut and queued in parts. Caller
+// must hold p.mu.
+func (p *Pipeline) listenLocked(chunk []float32, maxSamples int) {
+	h := p.handsFree
+	speaking := h.utterance != nil
+	if speaking {
+		h.utterance = append(h.utterance, chunk...)
+	} else {
+		h.lead.Write(chunk)
+	}
+
+	frames := h.vad.Process(chunk)
+	if h.skipping {
+		h.skipping = h.vad.InSpeech()
+		return
+	}
+
+	if !speaking && slices.Contains(frames, true) {
+		h.utterance = h.lead.Snapshot()
+		h.lead.Reset()
+		h.target = p.captureTarget()
+		speaking = true
+		p.log.Debug("Utterance started")
+	}
+	if speaking && (!h.vad.InSpeech() || len(h.utterance) >= maxSamples) {
+		p.flushUtteranceLocked()
+	}
+}
+
+// flushUtteranceLocked queues the utterance in progress for processing.
+// Caller must hold p.mu.
+func (p *Pipeline) flushUtteranceLocked() {
+	h := p.handsFree
+	if h == nil || h.utterance == nil {
+		return
+	}
+	p.log.Debug("Utterance ended", "samples", len(h.utterance), "queued", len(p.jobs))
+	p.enqueueLocked(h.utterance, nil, h.target, false)
+	h.utterance = nil
+	h.target = nil
+}
@@ -1,16 +1,21 @@
 package pipeline
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/cesp99/sussurro/internal/appstate"
 	"github.com/cesp99/sussurro/internal/asr"
 	"github.com/cesp99/sussurro/internal/audio"
 	"github.com/cesp99/sussurro/internal/clipboard"
 	ctxProvider "github.com/cesp99/sussurro/internal/context"
+	"github.com/cesp99/sussurro/internal/history"
 	"github.com/cesp99/sussurro/internal/injection"
 	"github.com/cesp99/sussurro/internal/llm"
 )
@@ -18,24 +23,37 @@ import (
 // StateNotifier receives pipeline state transitions and audio RMS values.
 // Implementations must be non-blocking (use channels / async dispatch internally).
 type StateNotifier interface {
-	// AppState values mirror ui.AppState to avoid an import cycle.
-	// 0=Idle, 1=Recording, 2=Transcribing
-	OnStateChange(state int)
+	OnStateChange(state appstate.State)
 	OnRMSData(rms float32)
+	// OnPartialTranscript receives the current best-guess transcription while
+	// recording is still in progress (only when streaming is enabled).
+	OnPartialTranscript(text string)
 }
 
+// ResultNotifier is an optional extension of StateNotifier for notifiers that
+// also want the final text of every completed dictation.
+type ResultNotifier interface {
+	OnResult(raw, cleaned string)
+}
+
+// defaultPreRoll is how much audio from before the hotkey press is kept
+// when audio.pre_roll is not set.
+const defaultPreRoll = 300 * time.Millisecond
+
 // Pipeline orchestrates the flow of data from audio capture to text output
 type Pipeline struct {
 	audioEngine *audio.CaptureEngine
 	asrEngine   *asr.Engine
 	llmEngine   *llm.Engine
 	ctxProvider ctxProvider.Provider
-	injector    *injection.Injector
+	injector    injection.Injector
 	log         *slog.Logger
 	vadParams   audio.VADParams
 
-	onCompletion func()        // Callback for when processing finishes
-	uiNotifier   StateNotifier // optional; nil means no UI
+	onCompletion func() // Callback for when processing finishes
+
+	notifyMu  sync.RWMutex
+	notifiers []StateNotifier // UI, control socket subscribers, ...
 
 	// Channels for data flow
 	audioChan chan []float32
@@ -43,11 +61,34 @@ type Pipeline struct {
 	wg        sync.WaitGroup
 
 	// State
-	isRecording    bool
-	isTranscribing bool // true while processSegment is running; blocks new recordings
-	audioBuffer    []float32
-	mu             sync.Mutex // Protects isRecording, isTranscribing, and audioBuffer
-	maxDuration    string
+	isRecording bool
+	raw         bool // the current recording skips LLM cleanup
+	audioBuffer []float32
+	preRoll     *audio.RingBuffer               // audio from just before the recording, filled while idle
+	stream      *stream                         // non-nil while a streaming recording is in progress
+	target      <-chan *ctxProvider.ContextInfo // window focused when the recording started
+	jobs        []*job                          // recordings being processed or waiting, oldest first; block new recordings
+	handsFree   *handsFree                      // non-nil while hands-free dictation is on
+	mu          sync.Mutex                      // Protects isRecording, raw, audioBuffer, preRoll, stream, target, jobs, handsFree, and lastText
+	maxDuration string
+
+	// Hands-free endpointing: the pause that ends an utterance
+	handsFreePause time.Duration
+
+	lastText string // last injected result, for ReinjectLast
+
+	onTargetClosed string // injection.TargetClosed* policy
+
+	contextAware bool // pass the focused app/window to the LLM
+
+	// Optional transcription history
+	history  *history.Store
+	asrModel string
+	llmModel string
+
+	// Streaming partial transcription (disabled when streamInterval is 0)
+	streamInterval time.Duration
+	streamWindow   time.Duration
 }
 
 // NewPipeline creates a new processing pipeline
@@ -56,7 +97,7 @@ func NewPipeline(
 	asrEngine *asr.Engine,
 	llmEngine *llm.Engine,
 	ctxProvider ctxProvider.Provider,
-	injector *injection.Injector,
+	injector injection.Injector,
 	log *slog.Logger,
 	sampleRate int,
 	maxDuration string,
@@ -64,7 +105,7 @@ func NewPipeline(
 	vadParams := audio.DefaultVADParams()
 	vadParams.SampleRate = sampleRate // Override with actual sample rate
 
-	return &Pipeline{
+	p := &Pipeline{
 		audioEngine: audioEngine,
 		asrEngine:   asrEngine,
 		llmEngine:   llmEngine,
@@ -76,6 +117,9 @@ func NewPipeline(
 		stopChan:    make(chan struct{}),
 		maxDuration: maxDuration,
 	}
+	p.SetPreRoll("")
+	p.SetHandsFreePause("")
+	return p
 }
 
 // SetOnCompletion sets a callback to be called when processing is done
@@ -83,27 +127,108 @@ func (p *Pipeline) SetOnCompletion(callback func()) {
 	p.onCompletion = callback
 }
 
-// SetUINotifier installs a StateNotifier for UI state updates.
+// AddNotifier installs a StateNotifier for state updates. Notifiers that
+// also implement ResultNotifier receive the final text of each dictation.
+func (p *Pipeline) AddNotifier(n StateNotifier) {
+	p.notifyMu.Lock()
+	p.notifiers = append(p.notifiers, n)
+	p.notifyMu.Unlock()
+}
+
+// SetStreaming enables partial transcription while the hotkey is held.
+// interval controls how often a partial hypothesis is produced and window how
+// much uncommitted audio may accumulate before its stable prefix is finalized.
+// Both are duration strings (e.g. "2s"); invalid values fall back to defaults.
 // Must be called before Start().
-func (p *Pipeline) SetUINotifier(n StateNotifier) {
-	p.uiNotifier = n
-	// Forward RMS data from the audio engine to the notifier
-	if n != nil {
-		p.audioEngine.SetRMSCallback(func(rms float32) {
-			p.mu.Lock()
-			recording := p.isRecording
-			p.mu.Unlock()
-			if recording {
-				n.OnRMSData(rms)
-			}
-		})
+func (p *Pipeline) SetStreaming(interval, window string) {
+	p.streamInterval = p.parseDuration("partial_interval", interval, 2*time.Second)
+	p.streamWindow = p.parseDuration("commit_window", window, 15*time.Second)
+	p.log.Debug("Streaming transcription enabled", "interval", p.streamInterval, "window", p.streamWindow)
+}
+
+// SetPreRoll sets how much audio from just before the hotkey goes down is
+// kept and prepended to each recording, so the first syllable is not cut
+// off. value is a duration string; "" means the default of 300ms and "0"
+// disables pre-roll. Must be called before Start().
+func (p *Pipeline) SetPreRoll(value string) {
+	var d time.Duration
+	if value = strings.TrimSpace(value); value != "0" {
+		d = p.parseDuration("pre_roll", value, defaultPreRoll)
+	}
+	p.preRoll = audio.NewRingBuffer(int(d.Seconds() * float64(p.vadParams.SampleRate)))
+}
+
+// SetContextAwareCleanup controls whether the focused application and window
+// title are passed to the LLM so cleanup can match the target's style.
+// Must be called before Start().
+func (p *Pipeline) SetContextAwareCleanup(enabled bool) {
+	p.contextAware = enabled
+}
+
+// SetOnTargetClosed sets what happens to a dictation whose window closed
+// before injection, as an injection.TargetClosed* value. The default is
+// injection.TargetClosedFocused. Must be called before Start().
+func (p *Pipeline) SetOnTargetClosed(policy string) {
+	p.onTargetClosed = policy
+}
+
+// SetHistory records every completed dictation in store, tagged with the
+// given model names. Must be called before Start().
+func (p *Pipeline) SetHistory(store *history.Store, asrModel, llmModel string) {
+	p.history = store
+	p.asrModel = asrModel
+	p.llmModel = llmModel
+}
+
+// parseDuration parses a duration setting, logging and returning def when the
+// value is empty or invalid.
+func (p *Pipeline) parseDuration(name, value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		p.log.Warn("Invalid duration, using default", "setting", name, "value", value, "default", def)
+		return def
+	}
+	return d
+}
+
+// notifyState sends a state change to every notifier.
+func (p *Pipeline) notifyState(state appstate.State) {
+	p.notifyMu.RLock()
+	defer p.notifyMu.RUnlock()
+	for _, n := range p.notifiers {
+		n.OnStateChange(state)
+	}
+}
+
+// notifyRMS sends a microphone level to every notifier.
+func (p *Pipeline) notifyRMS(rms float32) {
+	p.notifyMu.RLock()
+	defer p.notifyMu.RUnlock()
+	for _, n := range p.notifiers {
+		n.OnRMSData(rms)
 	}
 }
 
-// notifyState sends a state change to the UI notifier (nil-safe).
-func (p *Pipeline) notifyState(state int) {
-	if p.uiNotifier != nil {
-		p.uiNotifier.OnStateChange(state)
+// notifyPartial sends a partial transcript to every notifier.
+func (p *Pipeline) notifyPartial(text string) {
+	p.notifyMu.RLock()
+	defer p.notifyMu.RUnlock()
+	for _, n := range p.notifiers {
+		n.OnPartialTranscript(text)
+	}
+}
+
+// notifyResult sends the final text to every notifier that wants it.
+func (p *Pipeline) notifyResult(raw, cleaned string) {
+	p.notifyMu.RLock()
+	defer p.notifyMu.RUnlock()
+	for _, n := range p.notifiers {
+		if rn, ok := n.(ResultNotifier); ok {
+			rn.OnResult(raw, cleaned)
+		}
 	}
 }
 
@@ -111,6 +236,17 @@ func (p *Pipeline) notifyState(state int) {
 func (p *Pipeline) Start() error {
 	p.log.Debug("Starting pipeline")
 
+	// Forward RMS data from the audio engine to the notifiers while recording
+	// or listening hands-free
+	p.audioEngine.SetRMSCallback(func(rms float32) {
+		p.mu.Lock()
+		recording := p.isRecording || p.handsFree != nil
+		p.mu.Unlock()
+		if recording {
+			p.notifyRMS(rms)
+		}
+	})
+
 	// Start Audio Capture Loop (runs continuously to keep device ready)
 	p.wg.Add(1)
 	go p.captureLoop()
@@ -126,24 +262,118 @@ func (p *Pipeline) Stop() {
 	p.log.Debug("Pipeline stopped")
 }
 
-// StartRecording begins accumulating audio data
-func (p *Pipeline) StartRecording() {
+// StartRecording begins accumulating audio data.
+// Returns false if a recording or transcription is already in progress, or
+// hands-free dictation is on.
+func (p *Pipeline) StartRecording() bool {
+	return p.startRecording(false)
+}
+
+// StartRawRecording begins a recording whose transcript is injected as is,
+// without LLM cleanup.
+func (p *Pipeline) StartRawRecording() bool {
+	return p.startRecording(true)
+}
+
+func (p *Pipeline) startRecording(raw bool) bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.isRecording || p.isTranscribing {
-		return
+	if p.isRecording || len(p.jobs) > 0 || p.handsFree != nil {
+		return false
 	}
 
-	// Drain channel to ensure no stale audio is included
+	// Audio still queued was captured before the press, so it belongs to
+	// the pre-roll rather than the recording's own stream
 	for len(p.audioChan) > 0 {
-		<-p.audioChan
+		p.preRoll.Write(<-p.audioChan)
 	}
 
 	p.isRecording = true
-	p.audioBuffer = nil // Clear buffer
-	p.log.Debug("Recording started")
-	p.notifyState(1) // StateRecording
+	p.raw = raw
+	p.audioBuffer = p.preRoll.Snapshot()
+	p.preRoll.Reset()
+	p.target = p.captureTarget()
+	p.log.Debug("Recording started", "raw", raw)
+	p.notifyState(appstate.Recording)
+
+	if p.streamInterval > 0 {
+		p.stream = newStream()
+		p.wg.Add(1)
+		go p.streamLoop(p.stream)
+	}
+	return true
+}
+
+// CancelRecording discards the current recording without transcribing it.
+// Returns false if nothing was being recorded.
+func (p *Pipeline) CancelRecording() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.isRecording {
+		return false
+	}
+
+	p.isRecording = false
+	p.audioBuffer = nil
+	p.target = nil
+	if p.stream != nil {
+		close(p.stream.stop)
+		p.stream.cancel()
+		p.stream = nil
+	}
+	p.log.Debug("Recording cancelled")
+	p.notifyState(appstate.Idle)
+	return true
+}
+
+// Cancel discards the current recording, or aborts the transcription and
+// cleanup of the ones being processed so that nothing is injected. The LLM
+// stops at the next token; Whisper only checks between 30 s windows, so a
+// shorter transcription runs to the end and its result is dropped. In
+// hands-free mode the utterance being spoken is dropped too, and listening
+// goes on. Returns false if there was nothing to cancel.
+func (p *Pipeline) Cancel() bool {
+	if p.CancelRecording() {
+		return true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cancelled := p.handsFree != nil && p.handsFree.discard()
+	for _, j := range p.jobs {
+		if j.ctx.Err() == nil {
+			j.cancel()
+			cancelled = true
+		}
+	}
+	if cancelled {
+		p.log.Debug("Processing cancelled")
+	}
+	return cancelled
+}
+
+// State returns the current pipeline state.
+func (p *Pipeline) State() appstate.State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stateLocked()
+}
+
+// stateLocked returns the current state. Caller must hold p.mu.
+func (p *Pipeline) stateLocked() appstate.State {
+	switch {
+	case p.isRecording:
+		return appstate.Recording
+	case p.handsFree != nil:
+		return appstate.Listening // utterances are processed while listening goes on
+	case len(p.jobs) > 0:
+		return appstate.Transcribing
+	default:
+		return appstate.Idle
+	}
 }
 
 // StopRecording stops accumulating and triggers processing
@@ -156,19 +386,88 @@ func (p *Pipeline) StopRecording() bool {
 		return false
 	}
 
-	p.isRecording = false
-	p.isTranscribing = true
 	p.log.Debug("Recording stopped", "buffer_size", len(p.audioBuffer))
-	p.notifyState(2) // StateTranscribing
+	p.finishRecordingLocked()
+	return true
+}
 
-	// Process the captured audio in a separate goroutine to not block
-	// Make a copy of the buffer
+// finishRecordingLocked ends the current recording and queues the captured
+// audio for processing. Caller must hold p.mu.
+func (p *Pipeline) finishRecordingLocked() {
+	p.isRecording = false
+
+	// Make a copy of the buffer so capture can continue independently
 	bufferCopy := make([]float32, len(p.audioBuffer))
 	copy(bufferCopy, p.audioBuffer)
 
+	s := p.stream
+	p.stream = nil
+	if s != nil {
+		close(s.stop)
+	}
+
+	target := p.target
+	p.target = nil
+
+	p.enqueueLocked(bufferCopy, s, target, p.raw)
+	p.notifyState(p.stateLocked())
+}
+
+// job is a finished recording queued for processing. Jobs run one at a
+// time, in the order they were recorded, so hands-free utterances are
+// injected in the order they were spoken.
+type job struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	samples []float32
+	stream  *stream
+	target  <-chan *ctxProvider.ContextInfo
+	raw     bool
+
+	after <-chan struct{} // closed when the previous job is done; nil for the first
+	done  chan struct{}
+}
+
+// enqueueLocked queues samples for processSegment behind the jobs already
+// waiting. Caller must hold p.mu.
+func (p *Pipeline) enqueueLocked(samples []float32, s *stream, target <-chan *ctxProvider.ContextInfo, raw bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		ctx:     ctx,
+		cancel:  cancel,
+		samples: samples,
+		stream:  s,
+		target:  target,
+		raw:     raw,
+		done:    make(chan struct{}),
+	}
+	if n := len(p.jobs); n > 0 {
+		j.after = p.jobs[n-1].done
+	}
+	p.jobs = append(p.jobs, j)
+
 	p.wg.Add(1)
-	go p.processSegment(bufferCopy)
-	return true
+	go p.processSegment(j)
+}
+
+// captureTarget snapshots the focused window in the background, so the
+// recording starts without waiting on the context provider. The result is
+// what the dictation is cleaned up for and injected into.
+func (p *Pipeline) captureTarget() <-chan *ctxProvider.ContextInfo {
+	ch := make(chan *ctxProvider.ContextInfo, 1)
+	go func() { ch <- p.currentContext() }()
+	return ch
+}
+
+// currentContext returns the focused window, or an empty context if the
+// provider fails.
+func (p *Pipeline) currentContext() *ctxProvider.ContextInfo {
+	info, err := p.ctxProvider.GetContext()
+	if err != nil || info == nil {
+		p.log.Warn("Failed to get context", "error", err)
+		return &ctxProvider.ContextInfo{}
+	}
+	return info
 }
 
 func (p *Pipeline) captureLoop() {
@@ -180,6 +479,9 @@ func (p *Pipeline) captureLoop() {
 		p.log.Error("Failed to start recording", "error", err)
 		return
 	}
+	native := p.audioEngine.NativeFormat()
+	p.log.Info("Audio capture started", "native_rate", native.SampleRate, "native_channels", native.Channels,
+		"rate", p.audioEngine.SampleRate())
 
 	defer p.audioEngine.Stop()
 
@@ -208,24 +510,19 @@ func (p *Pipeline) captureLoop() {
 		select {
 		case chunk := <-p.audioChan:
 			p.mu.Lock()
-			if p.isRecording {
+			switch {
+			case p.isRecording:
 				// Safety check: Auto-stop if recording gets too long (prevents OOM/Stuck state)
 				if len(p.audioBuffer) >= maxSamples {
 					p.log.Warn("Max recording duration reached, forcing stop", "limit", p.maxDuration)
-					p.isRecording = false
-					p.isTranscribing = true
-					p.notifyState(2) // StateTranscribing
-
-					// Copy and process immediately
-					bufferCopy := make([]float32, len(p.audioBuffer))
-					copy(bufferCopy, p.audioBuffer)
-
-					// Launch processing in background
-					p.wg.Add(1)
-					go p.processSegment(bufferCopy)
+					p.finishRecordingLocked()
 				} else {
 					p.audioBuffer = append(p.audioBuffer, chunk...)
 				}
+			case p.handsFree != nil:
+				p.listenLocked(chunk, maxSamples)
+			default:
+				p.preRoll.Write(chunk)
 			}
 			p.mu.Unlock()
 
@@ -235,75 +532,130 @@ func (p *Pipeline) captureLoop() {
 	}
 }
 
-func (p *Pipeline) processSegment(samples []float32) {
+// processSegment transcribes, cleans up, and injects a finished recording
+// once the jobs queued before it are done. When j.stream is non-nil, the
+// audio before its committedSamples has already been transcribed during
+// recording and only the remaining tail is sent to Whisper. j.target
+// delivers the window that was focused when the recording started. A raw
+// job is injected without LLM cleanup. Cancelling j.ctx drops the result
+// and stops the engines as soon as they check it.
+func (p *Pipeline) processSegment(j *job) {
 	defer p.wg.Done()
 	defer func() {
 		if r := recover(); r != nil {
 			p.log.Error("Recovered from panic in processSegment", "error", r)
 		}
+		j.cancel() // release the context
+		close(j.done)
 		p.mu.Lock()
-		p.isTranscribing = false
+		p.jobs = slices.DeleteFunc(p.jobs, func(other *job) bool { return other == j })
+		// Notify under the lock so transitions reach subscribers in order
+		p.notifyState(p.stateLocked())
 		p.mu.Unlock()
-		p.notifyState(0) // StateIdle
 		if p.onCompletion != nil {
 			p.onCompletion()
 		}
 	}()
 
+	if j.after != nil {
+		<-j.after
+	}
+	ctx, samples, s, target, raw := j.ctx, j.samples, j.stream, j.target, j.raw
+	if ctx.Err() != nil {
+		if s != nil {
+			s.cancel()
+		}
+		p.log.Info("Dictation cancelled before processing")
+		return
+	}
+
 	if len(samples) == 0 {
 		p.log.Warn("Empty audio buffer, skipping processing")
 		return
 	}
 
-	// Check duration (SampleRate is typically 16000)
-	// If recording is less than 2 seconds, skip transcription
 	durationSeconds := float64(len(samples)) / float64(p.vadParams.SampleRate)
 	p.log.Debug("Processing segment", "samples", len(samples), "rate", p.vadParams.SampleRate, "duration", durationSeconds)
 
-	if durationSeconds < 2.0 {
-		p.log.Debug("Recording too short (< 2s), skipping transcription", "duration", durationSeconds)
+	// Skip recordings without speech, and trim the silence around it so
+	// Whisper has less to process and nothing to hallucinate on
+	speechStart, speechEnd, ok := audio.DetectSpeech(samples, p.vadParams)
+	if !ok {
+		p.log.Info("No speech detected, skipping transcription", "duration", durationSeconds)
 		return
 	}
+	p.log.Debug("Speech detected", "start", speechStart, "end", speechEnd)
 
 	start := time.Now()
 
-	// 1. ASR: Transcribe Audio
-	text, err := p.asrEngine.Transcribe(samples)
-	if err != nil {
-		p.log.Error("ASR failed", "error", err)
+	// 1. ASR: Transcribe Audio (only the uncommitted tail when streaming)
+	var text string
+	from := speechStart
+	if s != nil {
+		// Wait for any in-flight partial transcription, which a cancel stops
+		stopStream := context.AfterFunc(ctx, s.cancel)
+		<-s.done
+		stopStream()
+		text = s.committedText
+		from = max(from, s.committedSamples)
+		p.log.Debug("Finalizing streamed recording", "committed_samples", s.committedSamples, "tail_samples", max(speechEnd-from, 0))
+	}
+	tail := samples[min(from, speechEnd):speechEnd]
+	tailText, err := p.asrEngine.TranscribeContext(ctx, tail)
+	if ctx.Err() != nil {
+		p.log.Info("Dictation cancelled during transcription")
 		return
 	}
-
-	// Check word count
-	// If detected less than 4 words, avoid transcribing completely (treat as false positive)
-	// We do this after transcription as we need the text to count words
-	words := strings.Fields(text)
-	if len(words) < 4 {
-		p.log.Debug("Transcription too short (< 4 words), ignoring", "text", text, "word_count", len(words))
+	if err != nil {
+		p.log.Error("ASR failed", "error", err)
 		return
 	}
+	text += tailText
+	asrDuration := time.Since(start)
 
 	if strings.TrimSpace(text) == "" {
-		p.log.Debug("No speech detected")
+		p.log.Debug("Empty transcription, skipping")
 		return
 	}
 
 	p.log.Debug("ASR Output", "text", text, "duration", time.Since(start))
 
-	// 2. Context: Get Current Window Info
-	ctxInfo, err := p.ctxProvider.GetContext()
-	if err != nil {
-		p.log.Warn("Failed to get context", "error", err)
-		// Proceed without context
+	// 2. Context: the window focused when recording started
+	ctxInfo := <-target
+
+	// 3. LLM: Cleanup and Contextualize (skipped for raw dictation)
+	var cleanedText string
+	var llmDuration time.Duration
+	llmModel := p.llmModel
+	if raw {
+		cleanedText = strings.TrimSpace(text)
+		llmModel = ""
+	} else {
+		var llmTarget *llm.TargetContext
+		if p.contextAware {
+			llmTarget = &llm.TargetContext{
+				AppName:     ctxInfo.AppName,
+				WindowTitle: ctxInfo.WindowTitle,
+			}
+		}
+		llmStart := time.Now()
+		cleanedText, err = p.llmEngine.CleanupTextWithContext(ctx, text, llmTarget)
+		if ctx.Err() != nil {
+			p.log.Info("Dictation cancelled during cleanup")
+			return
+		}
+		if err != nil {
+			p.log.Error("LLM cleanup failed", "error", err)
+			// Fallback to raw text
+			cleanedText = text
+		}
+		llmDuration = time.Since(llmStart)
 	}
 
-	// 3. LLM: Cleanup and Contextualize
-	// TODO: Pass context info to LLM if supported
-	cleanedText, err := p.llmEngine.CleanupText(text)
-	if err != nil {
-		p.log.Error("LLM cleanup failed", "error", err)
-		// Fallback to raw text
-		cleanedText = text
+	// Nothing is recorded or injected once cancelled
+	if ctx.Err() != nil {
+		p.log.Info("Dictation cancelled")
+		return
 	}
 
 	p.log.Info("Final Output",
@@ -314,19 +666,97 @@ func (p *Pipeline) processSegment(samples []float32) {
 		"total_duration", time.Since(start),
 	)
 
-	// 4. Output: Print to Stdout
-	fmt.Println(cleanedText)
+	// Record before injecting so the text survives a paste into the wrong window
+	if p.history != nil {
+		entry := history.Entry{
+			Raw:      strings.TrimSpace(text),
+			Cleaned:  cleanedText,
+			App:      ctxInfo.AppName,
+			Window:   ctxInfo.WindowTitle,
+			ASRModel: p.asrModel,
+			LLMModel: llmModel,
+			Timings: history.Timings{
+				AudioMS: int64(durationSeconds * 1000),
+				ASRMS:   asrDuration.Milliseconds(),
+				LLMMS:   llmDuration.Milliseconds(),
+				TotalMS: time.Since(start).Milliseconds(),
+			},
+		}
+		if _, err := p.history.Append(entry); err != nil {
+			p.log.Warn("Failed to record history", "error", err)
+		}
+	}
+
+	p.mu.Lock()
+	p.lastText = cleanedText
+	p.mu.Unlock()
 
-	// 5. Output: Inject Text
-	// First write to clipboard as backup/mechanism
-	if err := clipboard.Write(cleanedText); err != nil {
-		p.log.Error("Failed to write to clipboard", "error", err)
+	// 4. Output: Print to Stdout (unless that is the injection method)
+	if p.injector == nil || p.injector.Method() != injection.MethodStdout {
+		fmt.Println(cleanedText)
 	}
 
-	// Then inject via keyboard
+	// 5. Output: Return focus to the recording's window and inject
 	if p.injector != nil {
-		if err := p.injector.Inject(cleanedText); err != nil {
-			p.log.Error("Failed to inject text", "error", err)
+		if dest := p.refocus(ctxInfo, cleanedText); dest != nil {
+			method := p.injector.MethodFor(dest)
+			p.log.Debug("Injecting text", "method", method, "app", dest.AppName)
+			if err := p.injector.Inject(cleanedText, dest); err != nil {
+				p.log.Error("Failed to inject text", "method", method, "error", err)
+			}
+		}
+	}
+
+	p.notifyResult(strings.TrimSpace(text), cleanedText)
+}
+
+// ReinjectLast injects the last result again into the focused window.
+func (p *Pipeline) ReinjectLast() error {
+	p.mu.Lock()
+	text := p.lastText
+	p.mu.Unlock()
+
+	if text == "" {
+		return errors.New("nothing has been dictated yet")
+	}
+	if p.injector == nil {
+		return errors.New("no injector configured")
+	}
+	target := p.currentContext()
+	p.log.Debug("Re-injecting last result", "method", p.injector.MethodFor(target))
+	return p.injector.Inject(text, target)
+}
+
+// refocus re-activates the window the dictation was recorded in and returns
+// the context to inject into, or nil when the text must not be injected
+// because that window has closed.
+func (p *Pipeline) refocus(target *ctxProvider.ContextInfo, text string) *ctxProvider.ContextInfo {
+	switch p.injector.MethodFor(target) {
+	case injection.MethodClipboard, injection.MethodStdout, injection.MethodNone:
+		return target // focus does not matter
+	}
+
+	err := p.ctxProvider.Activate(target)
+	if err == nil {
+		return target
+	}
+	if !errors.Is(err, ctxProvider.ErrWindowClosed) {
+		p.log.Warn("Failed to restore focus, injecting into the focused window", "app", target.AppName, "error", err)
+		return p.currentContext()
+	}
+
+	switch p.onTargetClosed {
+	case injection.TargetClosedClipboard:
+		p.log.Warn("Target window closed, copying text to the clipboard", "app", target.AppName, "window", target.WindowTitle)
+		if err := clipboard.Write(text); err != nil {
+			p.log.Error("Failed to copy text to clipboard", "error", err)
 		}
+		return nil
+	case injection.TargetClosedDiscard:
+		p.log.Warn("Target window closed, discarding text", "app", target.AppName, "window", target.WindowTitle)
+		return nil
+	default:
+		p.log.Warn("Target window closed, injecting into the focused window", "app", target.AppName, "window", target.WindowTitle)
+		return p.currentContext()
 	}
 }
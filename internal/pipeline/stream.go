@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// stream holds the state of a single streaming recording. Audio before
+// committedSamples has been transcribed into committedText and will not be
+// sent to Whisper again; everything after it is re-transcribed on each tick.
+//
+// committedText and committedSamples are owned by streamLoop until done is
+// closed, after which processSegment may read them.
+type stream struct {
+	committedText    string
+	committedSamples int
+	lastPartial      string
+
+	stop chan struct{} // closed by finishRecordingLocked
+	done chan struct{} // closed when streamLoop returns
+
+	ctx    context.Context // cancelled to abort an in-flight partial transcription
+	cancel context.CancelFunc
+}
+
+func newStream() *stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &stream{
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// streamLoop periodically transcribes the uncommitted part of the recording
+// and emits a partial hypothesis until the recording stops.
+func (p *Pipeline) streamLoop(s *stream) {
+	defer p.wg.Done()
+	defer close(s.done)
+	defer s.cancel()
+
+	ticker := time.NewTicker(p.streamInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+			p.streamTick(s)
+		}
+	}
+}
+
+// streamTick transcribes the pending audio once. When the pending audio is
+// longer than the commit window, every segment but the last is finalized so
+// that release only has to transcribe the tail.
+func (p *Pipeline) streamTick(s *stream) {
+	rate := p.vadParams.SampleRate
+
+	p.mu.Lock()
+	if p.stream != s {
+		p.mu.Unlock()
+		return
+	}
+	pending := make([]float32, len(p.audioBuffer)-s.committedSamples)
+	copy(pending, p.audioBuffer[s.committedSamples:])
+	p.mu.Unlock()
+
+	// Whisper output on less than a second of audio is mostly noise
+	if len(pending) < rate {
+		return
+	}
+
+	segments, err := p.asrEngine.TranscribeSegmentsContext(s.ctx, pending)
+	if s.ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		p.log.Warn("Partial transcription failed", "error", err)
+		return
+	}
+
+	windowSamples := int(p.streamWindow.Seconds() * float64(rate))
+	if len(pending) >= windowSamples && len(segments) > 0 {
+		// Keep the last segment open unless the window is badly overrun,
+		// since Whisper is still likely to revise it as more audio arrives.
+		commit := len(segments) - 1
+		if commit == 0 && len(pending) >= 2*windowSamples {
+			commit = 1
+		}
+		if commit > 0 {
+			end := int(segments[commit-1].End.Seconds() * float64(rate))
+			if end > 0 && end <= len(pending) {
+				for _, seg := range segments[:commit] {
+					s.committedText += seg.Text
+				}
+				s.committedSamples += end
+				segments = segments[commit:]
+				p.log.Debug("Committed streamed audio", "committed_samples", s.committedSamples)
+			}
+		}
+	}
+
+	partial := s.committedText
+	for _, seg := range segments {
+		partial += seg.Text
+	}
+	partial = strings.TrimSpace(partial)
+
+	// Don't report a stale hypothesis once the user has released the key
+	select {
+	case <-s.stop:
+		return
+	default:
+	}
+
+	if partial != "" && partial != s.lastPartial {
+		s.lastPartial = partial
+		p.log.Debug("Partial transcript", "text", partial)
+		p.notifyPartial(partial)
+	}
+}
@@ -74,28 +74,65 @@ const (
   log_level: "info" # debug, info, warn, error
 
 audio:
-  sample_rate: 16000
-  channels: 1
+  device: "" # microphone name or ID from 'sussurro devices'; empty for the system default
+  sample_rate: 16000 # rate delivered to Whisper; the microphone is resampled from its native rate
+  channels: 1 # mono; multi-channel microphones are downmixed
   bit_depth: 16
   buffer_size: 1024
   max_duration: "60s"
+  pre_roll: "300ms" # audio kept from just before the hotkey press; "0" to disable
 
 models:
   asr:
     path: "{{ASR_PATH}}"
     type: "whisper"
     threads: 4
+    streaming: true
+    partial_interval: "2s"
+    commit_window: "15s"
   llm:
     path: "{{LLM_PATH}}"
     context_size: 32768
     gpu_layers: 0
     threads: 4
+    context_aware: false
 
 hotkey:
   trigger: "ctrl+shift+space"
+  backend: "auto" # auto, or evdev to read keyboards from /dev/input (Linux)
+  mode: "hold" # hold, toggle, or latch (tap to keep recording, press again to stop)
+  tap_threshold: "300ms" # latch: presses shorter than this are taps
+  # More triggers mapped to actions: dictate, dictate_raw (skip LLM cleanup),
+  # cancel, reinject (paste the last result again), settings, or handsfree
+  # (turn hands-free dictation on or off).
+  # bindings:
+  #   - trigger: "ctrl+shift+r"
+  #     action: "dictate_raw"
+  #   - trigger: "ctrl+shift+x"
+  #     action: "cancel"
+
+handsfree:
+  pause: "800ms" # silence that ends an utterance in hands-free dictation
 
 injection:
-  method: "keyboard"
+  method: "paste" # paste, type, clipboard, stdout, or none
+  restore_clipboard: true
+  restore_delay: "500ms"
+  on_target_closed: "focused" # focused, clipboard, or discard
+  keyboard: "auto" # Linux: auto, uinput, or tools (how "type" sends keys)
+  # Per-app overrides, matched on the app name / WM_CLASS. Common Linux
+  # terminals already paste with ctrl+shift+v (xterm and urxvt type).
+  # apps:
+  #   - match: ["code", "Code"]
+  #     paste_keys: "ctrl+shift+v"
+  #     pre_delay: "50ms"
+  #   - match: ["keepassxc"]
+  #     method: "type"
+
+history:
+  enabled: false
+  max_entries: 1000
+  max_age: "720h" # 30 days
 `
 	// Whisper Small model
 	urlASRSmall  = "https://huggingface.co/ggerganov/whisper.cpp/resolve/main/ggml-small.bin"
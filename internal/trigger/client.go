@@ -0,0 +1,103 @@
+package trigger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// dialTimeout bounds how long a client waits to connect to the socket.
+const dialTimeout = 2 * time.Second
+
+// Reply is a parsed reply line: "OK <state>[ <detail>]" or
+// "ERR <state> <message>".
+type Reply struct {
+	OK     bool
+	State  string
+	Detail string // detail for OK, error message for ERR
+}
+
+// ParseReply parses a single reply line from the server.
+func ParseReply(line string) (Reply, error) {
+	status, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	state, detail, _ := strings.Cut(rest, " ")
+
+	var r Reply
+	switch status {
+	case "OK":
+		r.OK = true
+	case "ERR":
+	default:
+		return Reply{}, fmt.Errorf("malformed reply %q", line)
+	}
+	if state == "" {
+		return Reply{}, fmt.Errorf("malformed reply %q", line)
+	}
+	r.State = state
+	r.Detail = detail
+	return r, nil
+}
+
+// Client is a connection to the control socket of a running instance.
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Dial connects to the control socket at SocketPath.
+func Dial() (*Client, error) {
+	return DialPath(SocketPath())
+}
+
+// DialPath connects to the control socket at path.
+func DialPath(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// Send writes one command line and reads its reply.
+func (c *Client) Send(cmd string) (Reply, error) {
+	if _, err := c.conn.Write([]byte(cmd + "\n")); err != nil {
+		return Reply{}, fmt.Errorf("failed to send command: %w", err)
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return Reply{}, fmt.Errorf("failed to read reply: %w", err)
+	}
+	return ParseReply(line)
+}
+
+// Subscribe switches the connection to event streaming. After a successful
+// reply, events are read with NextEvent; the first one is the current state.
+func (c *Client) Subscribe(options ...string) (Reply, error) {
+	return c.Send(strings.Join(append([]string{CmdSubscribe}, options...), " "))
+}
+
+// NextEvent blocks until the next event arrives on a subscribed connection.
+func (c *Client) NextEvent() (Event, error) {
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		return Event{}, err
+	}
+	var ev Event
+	if err := json.Unmarshal(line, &ev); err != nil {
+		return Event{}, fmt.Errorf("malformed event: %w", err)
+	}
+	return ev, nil
+}
+
+// SetDeadline bounds all further reads and writes on the connection.
+func (c *Client) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,189 @@
+package trigger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cesp99/sussurro/internal/appstate"
+)
+
+// Event types sent to subscribers.
+const (
+	EventState   = "state"
+	EventRMS     = "rms"
+	EventPartial = "partial"
+	EventResult  = "result"
+)
+
+// Subscription defaults. RMS events are only sent to subscribers that ask
+// for them, at most once per interval.
+const (
+	defaultRMSInterval = 100 * time.Millisecond
+	minRMSInterval     = 20 * time.Millisecond
+	subscriberBuffer   = 64
+	writeTimeout       = 5 * time.Second
+)
+
+// Event is one line of the subscribe stream, encoded as JSON.
+type Event struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	State string    `json:"state,omitempty"`
+	RMS   *float32  `json:"rms,omitempty"`
+	Text  string    `json:"text,omitempty"`
+	Raw   string    `json:"raw,omitempty"`
+}
+
+// subscriber is a connection in subscribe mode. Events are queued on a
+// buffered channel so that a slow client never blocks the pipeline; when the
+// buffer is full the event is dropped for that client.
+type subscriber struct {
+	events      chan Event
+	rmsInterval time.Duration // 0 disables RMS events
+	lastRMS     time.Time
+}
+
+// parseSubscribeOptions parses the arguments of the subscribe command:
+// "rms" enables throttled RMS events, "rms=<duration>" sets the interval.
+func parseSubscribeOptions(args []string) (time.Duration, error) {
+	var rmsInterval time.Duration
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(strings.ToLower(arg), "=")
+		if name != EventRMS {
+			return 0, fmt.Errorf("unknown subscribe option %q", arg)
+		}
+		rmsInterval = defaultRMSInterval
+		if hasValue {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return 0, fmt.Errorf("invalid rms interval %q", value)
+			}
+			rmsInterval = max(d, minRMSInterval)
+		}
+	}
+	return rmsInterval, nil
+}
+
+// subscribe switches conn to event streaming. It replies to the command,
+// sends the current state as the first event, and then forwards events until
+// the client disconnects or the server stops.
+func (s *Server) subscribe(conn net.Conn, scanner *bufio.Scanner, rmsInterval time.Duration) {
+	sub := &subscriber{
+		events:      make(chan Event, subscriberBuffer),
+		rmsInterval: rmsInterval,
+	}
+
+	// Register before reading the state so no transition is missed. The
+	// state must not be read under subMu since the pipeline publishes while
+	// holding its own lock.
+	s.subMu.Lock()
+	s.subs[sub] = struct{}{}
+	s.subMu.Unlock()
+
+	defer func() {
+		s.subMu.Lock()
+		delete(s.subs, sub)
+		s.subMu.Unlock()
+	}()
+
+	s.log.Debug("Event subscriber connected", "rms_interval", rmsInterval)
+	if !s.writeLine(conn, s.okReply("")) {
+		return
+	}
+
+	// Drop the transitions queued since registering, then take the
+	// snapshot: it is at least as new as any of them, and every later
+	// transition is queued behind it. Other events keep their order.
+	var pending []Event
+drain:
+	for {
+		select {
+		case ev := <-sub.events:
+			if ev.Event != EventState {
+				pending = append(pending, ev)
+			}
+		default:
+			break drain
+		}
+	}
+	initial := Event{Event: EventState, Time: time.Now(), State: StateName(s.ctrl.State())}
+
+	enc := json.NewEncoder(conn)
+	enc.SetEscapeHTML(false)
+	for _, ev := range append([]Event{initial}, pending...) {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := enc.Encode(ev); err != nil {
+			return
+		}
+	}
+
+	// Subscriptions are long lived; only watch the read side for EOF
+	conn.SetReadDeadline(time.Time{})
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for scanner.Scan() {
+		}
+	}()
+
+	for {
+		select {
+		case ev := <-sub.events:
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := enc.Encode(ev); err != nil {
+				return
+			}
+		case <-closed:
+			s.log.Debug("Event subscriber disconnected")
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// publish queues ev for every subscriber without blocking.
+func (s *Server) publish(ev Event) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for sub := range s.subs {
+		if ev.Event == EventRMS {
+			if sub.rmsInterval == 0 || ev.Time.Sub(sub.lastRMS) < sub.rmsInterval {
+				continue
+			}
+			sub.lastRMS = ev.Time
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			if ev.Event != EventRMS {
+				s.log.Debug("Event subscriber too slow, dropping event", "event", ev.Event)
+			}
+		}
+	}
+}
+
+// OnStateChange implements pipeline.StateNotifier.
+func (s *Server) OnStateChange(state appstate.State) {
+	s.publish(Event{Event: EventState, Time: time.Now(), State: StateName(state)})
+}
+
+// OnRMSData implements pipeline.StateNotifier.
+func (s *Server) OnRMSData(rms float32) {
+	s.publish(Event{Event: EventRMS, Time: time.Now(), RMS: &rms})
+}
+
+// OnPartialTranscript implements pipeline.StateNotifier.
+func (s *Server) OnPartialTranscript(text string) {
+	s.publish(Event{Event: EventPartial, Time: time.Now(), Text: text})
+}
+
+// OnResult implements pipeline.ResultNotifier.
+func (s *Server) OnResult(raw, cleaned string) {
+	s.publish(Event{Event: EventResult, Time: time.Now(), Text: cleaned, Raw: raw})
+}
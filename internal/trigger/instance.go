@@ -0,0 +1,90 @@
+package trigger
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrAlreadyRunning is returned when another Sussurro instance holds the
+// instance lock or is serving the control socket.
+var ErrAlreadyRunning = errors.New("sussurro is already running")
+
+// InstanceLock is a per-user advisory lock held for the lifetime of the
+// process. The kernel drops it when the process exits, so a crashed instance
+// never leaves a stale lock behind.
+type InstanceLock struct {
+	f *os.File
+}
+
+// runtimeDir returns $XDG_RUNTIME_DIR, or /tmp when it is not set.
+func runtimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir
+	}
+	return "/tmp"
+}
+
+// LockPath returns the location of the instance lock file. Outside
+// $XDG_RUNTIME_DIR the file name carries the user ID, since /tmp is shared.
+func LockPath() string {
+	if os.Getenv("XDG_RUNTIME_DIR") == "" {
+		return filepath.Join("/tmp", fmt.Sprintf("sussurro-%d.lock", os.Getuid()))
+	}
+	return filepath.Join(runtimeDir(), "sussurro.lock")
+}
+
+// AcquireInstanceLock takes the instance lock without blocking. It returns
+// ErrAlreadyRunning if another process holds it.
+func AcquireInstanceLock() (*InstanceLock, error) {
+	path := LockPath()
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open instance lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrAlreadyRunning
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// The PID is informational only; the flock is what counts
+	f.Truncate(0)
+	fmt.Fprintf(f, "%d\n", os.Getpid())
+
+	return &InstanceLock{f: f}, nil
+}
+
+// Release drops the lock. The file is left in place: unlinking it would let
+// a concurrent starter lock a different inode.
+func (l *InstanceLock) Release() {
+	syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+	l.f.Close()
+}
+
+// removeStaleSocket deletes a socket left behind by an instance that exited
+// without cleaning up. A socket that still accepts connections belongs to a
+// live instance and is left alone, as is anything that is not a socket.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+		conn.Close()
+		return ErrAlreadyRunning
+	}
+	return os.Remove(path)
+}
@@ -1,49 +1,137 @@
 package trigger
 
 import (
+	"bufio"
 	"fmt"
 	"log/slog"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cesp99/sussurro/internal/appstate"
+	"github.com/cesp99/sussurro/internal/version"
 )
 
-// Server listens for trigger events via UNIX socket
+// StateName returns the protocol name of a pipeline state.
+func StateName(state appstate.State) string {
+	switch state {
+	case appstate.Idle:
+		return "idle"
+	case appstate.Recording:
+		return "recording"
+	case appstate.Transcribing:
+		return "transcribing"
+	case appstate.Listening:
+		return "listening"
+	default:
+		return "unknown"
+	}
+}
+
+// Controller is the part of the pipeline driven by the trigger socket.
+// The server never tracks recording state itself; every reply is built from
+// Controller.State so it cannot drift from the pipeline.
+type Controller interface {
+	StartRecording() bool
+	StopRecording() bool
+	Cancel() bool
+	SetHandsFree(on bool) bool
+	HandsFree() bool
+	State() appstate.State
+}
+
+// Commands understood by the server, one per line.
+const (
+	CmdStart     = "start"
+	CmdStop      = "stop"
+	CmdToggle    = "toggle"
+	CmdPress     = "press"
+	CmdRelease   = "release"
+	CmdCancel    = "cancel"
+	CmdHandsFree = "handsfree"
+	CmdStatus    = "status"
+	CmdVersion   = "version"
+	CmdSettings  = "settings"
+	CmdSubscribe = "subscribe"
+)
+
+// readTimeout bounds how long an idle client may hold a connection open.
+const readTimeout = 30 * time.Second
+
+// Server listens for trigger commands via UNIX socket.
+//
+// The protocol is line based: each command line gets exactly one reply line
+// of the form "OK <state>[ <detail>]" or "ERR <state> <message>", where
+// <state> is the pipeline state after the command ran. The subscribe command
+// instead turns the connection into a stream of JSON events (see Event).
+//
+// Server implements pipeline.StateNotifier and pipeline.ResultNotifier so it
+// can be registered with Pipeline.AddNotifier to feed its subscribers.
 type Server struct {
-	socket     string
-	listener   net.Listener
-	log        *slog.Logger
-	done       chan struct{}
-	onKeyDown  func()
-	onKeyUp    func()
-	isRecording bool
+	socket   string
+	listener net.Listener
+	log      *slog.Logger
+	done     chan struct{}
+	ctrl     Controller
+
+	mu           sync.Mutex
+	openSettings func()
+	keyDown      func()
+	keyUp        func()
+
+	subMu sync.Mutex
+	subs  map[*subscriber]struct{}
 }
 
-// NewServer creates a new trigger server
-func NewServer(log *slog.Logger) (*Server, error) {
-	// Create socket in user's runtime directory
-	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
-	if runtimeDir == "" {
-		runtimeDir = "/tmp"
-	}
+// SocketPath returns the location of the control socket:
+// $XDG_RUNTIME_DIR/sussurro.sock, or /tmp/sussurro.sock as a fallback.
+func SocketPath() string {
+	return filepath.Join(runtimeDir(), "sussurro.sock")
+}
 
-	socketPath := filepath.Join(runtimeDir, "sussurro.sock")
+// NewServer creates a new trigger server. A socket left behind by a crashed
+// instance is removed; ErrAlreadyRunning is returned if a live instance is
+// still serving it.
+func NewServer(log *slog.Logger) (*Server, error) {
+	socketPath := SocketPath()
 
-	// Remove existing socket if present
-	os.Remove(socketPath)
+	if err := removeStaleSocket(socketPath); err != nil {
+		return nil, err
+	}
 
 	return &Server{
 		socket: socketPath,
 		log:    log,
 		done:   make(chan struct{}),
+		subs:   make(map[*subscriber]struct{}),
 	}, nil
 }
 
-// Start starts listening for trigger events
-func (s *Server) Start(onKeyDown, onKeyUp func()) error {
-	s.onKeyDown = onKeyDown
-	s.onKeyUp = onKeyUp
+// SetOpenSettings installs the handler for the settings command. Without
+// one (headless mode) the command is rejected.
+func (s *Server) SetOpenSettings(fn func()) {
+	s.mu.Lock()
+	s.openSettings = fn
+	s.mu.Unlock()
+}
+
+// SetHotkey installs the handlers for the press and release commands, which
+// report the hotkey going down and up so hotkey.mode decides what happens.
+// Without them both commands are rejected.
+func (s *Server) SetHotkey(keyDown, keyUp func()) {
+	s.mu.Lock()
+	s.keyDown = keyDown
+	s.keyUp = keyUp
+	s.mu.Unlock()
+}
+
+// Start starts listening for trigger commands
+func (s *Server) Start(ctrl Controller) error {
+	s.ctrl = ctrl
 
 	listener, err := net.Listen("unix", s.socket)
 	if err != nil {
@@ -95,37 +183,168 @@ func (s *Server) listen() {
 func (s *Server) handleConnection(conn net.Conn) {
 	defer conn.Close()
 
-	buf := make([]byte, 1024)
-	n, err := conn.Read(buf)
-	if err != nil {
-		return
+	scanner := bufio.NewScanner(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		if !scanner.Scan() {
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if strings.ToLower(fields[0]) == CmdSubscribe {
+			rmsInterval, err := parseSubscribeOptions(fields[1:])
+			if err != nil {
+				if !s.writeLine(conn, s.errorReply(err.Error())) {
+					return
+				}
+				continue
+			}
+			s.subscribe(conn, scanner, rmsInterval)
+			return
+		}
+
+		if !s.writeLine(conn, s.execute(line)) {
+			return
+		}
 	}
+}
+
+// writeLine sends a single reply line and reports whether it succeeded.
+func (s *Server) writeLine(conn net.Conn, line string) bool {
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := conn.Write([]byte(line + "\n"))
+	return err == nil
+}
 
-	cmd := string(buf[:n])
+// execute runs a single command line and returns the reply line.
+func (s *Server) execute(line string) string {
+	cmd := strings.ToLower(strings.Fields(line)[0])
 	s.log.Debug("Received trigger command", "cmd", cmd)
 
-	// Toggle recording state
-	if !s.isRecording {
-		s.log.Info("Recording started - press hotkey again when done speaking")
-		s.isRecording = true
-		if s.onKeyDown != nil {
-			s.onKeyDown()
+	switch cmd {
+	case CmdStart:
+		return s.start()
+
+	case CmdStop:
+		return s.stop()
+
+	case CmdToggle:
+		if s.ctrl.State() == appstate.Recording {
+			return s.stop()
 		}
-		conn.Write([]byte("RECORDING\n"))
-	} else {
-		s.log.Info("Recording stopped - processing...")
-		s.isRecording = false
-		if s.onKeyUp != nil {
-			s.onKeyUp()
+		return s.start()
+
+	case CmdPress, CmdRelease:
+		s.mu.Lock()
+		handler := s.keyDown
+		if cmd == CmdRelease {
+			handler = s.keyUp
 		}
-		conn.Write([]byte("STOPPED\n"))
+		s.mu.Unlock()
+		if handler == nil {
+			return s.errorReply("no hotkey handler")
+		}
+		handler()
+		return s.okReply("")
+
+	case CmdCancel:
+		if !s.ctrl.Cancel() {
+			return s.errorReply("nothing to cancel")
+		}
+		s.log.Info("Dictation cancelled")
+		return s.okReply("")
+
+	case CmdHandsFree:
+		return s.handsFree(strings.Fields(line)[1:])
+
+	case CmdStatus:
+		return s.okReply("")
+
+	case CmdVersion:
+		return s.okReply(version.Version)
+
+	case CmdSettings:
+		s.mu.Lock()
+		openSettings := s.openSettings
+		s.mu.Unlock()
+		if openSettings == nil {
+			return s.errorReply("no settings window (running headless)")
+		}
+		openSettings()
+		return s.okReply("")
+
+	default:
+		return s.errorReply(fmt.Sprintf("unknown command %q", cmd))
+	}
+}
+
+func (s *Server) start() string {
+	switch s.ctrl.State() {
+	case appstate.Recording:
+		// Idempotent so a repeated key-press binding is harmless
+		return s.okReply("")
+	case appstate.Transcribing:
+		return s.errorReply("busy")
+	case appstate.Listening:
+		return s.errorReply("hands-free dictation is on")
+	}
+	if !s.ctrl.StartRecording() {
+		return s.errorReply("could not start recording")
 	}
+	s.log.Info("Recording started - send stop (or toggle) when done speaking")
+	return s.okReply("")
+}
+
+func (s *Server) stop() string {
+	if !s.ctrl.StopRecording() {
+		return s.errorReply("not recording")
+	}
+	s.log.Info("Recording stopped - processing...")
 
 	// Try to send desktop notification if notify-send is available
-	if !s.isRecording {
-		// Just finished recording
-		exec.Command("notify-send", "-t", "2000", "Sussurro", "Processing your speech...").Start()
+	exec.Command("notify-send", "-t", "2000", "Sussurro", "Processing your speech...").Start()
+
+	return s.okReply("")
+}
+
+// handsFree turns hands-free dictation on or off, or toggles it without an
+// argument.
+func (s *Server) handsFree(args []string) string {
+	on := !s.ctrl.HandsFree()
+	switch {
+	case len(args) == 0:
+	case len(args) == 1 && strings.EqualFold(args[0], "on"):
+		on = true
+	case len(args) == 1 && strings.EqualFold(args[0], "off"):
+		on = false
+	default:
+		return s.errorReply("usage: handsfree [on|off]")
 	}
+	if !s.ctrl.SetHandsFree(on) {
+		return s.errorReply("recording in progress")
+	}
+	if on {
+		s.log.Info("Hands-free dictation on - pause to send each utterance")
+	} else {
+		s.log.Info("Hands-free dictation off")
+	}
+	return s.okReply("")
+}
+
+func (s *Server) okReply(detail string) string {
+	reply := "OK " + StateName(s.ctrl.State())
+	if detail != "" {
+		reply += " " + detail
+	}
+	return reply
+}
+
+func (s *Server) errorReply(msg string) string {
+	return "ERR " + StateName(s.ctrl.State()) + " " + msg
 }
 
 // GetSocketPath returns the socket path for external triggering
@@ -4,25 +4,48 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cesp99/sussurro/internal/audio"
 	"github.com/cesp99/sussurro/internal/config"
+	ihk "github.com/cesp99/sussurro/internal/hotkey"
 )
 
 // Manager is the top-level UI controller.
 // It implements StateNotifier so the pipeline can call it directly.
 type Manager struct {
-	cfg      *config.Config
-	overlay  Overlay
-	settings *settingsWindow
+	cfg     *config.Config
+	overlay Overlay
+
+	// settings is created by Run; OpenSettings may be called earlier.
+	settingsMu      sync.Mutex
+	settings        *settingsWindow
+	pendingSettings bool
 
 	// Channels for thread-safe state delivery from pipeline goroutines.
 	stateChangeCh chan AppState
 	rmsCh         chan float32
+	partialCh     chan string
 	quitCh        chan struct{}
 	quitOnce      sync.Once
 
-	// Stored hotkey callbacks so the hotkey can be re-registered at runtime.
-	hotkeyOnDown func()
-	hotkeyOnUp   func()
+	// Stored hotkey actions so the hotkeys can be re-registered at runtime.
+	hotkeyBindings []ihk.Binding
+	hotkeyActions  ihk.Actions
+
+	// onHotkeyChange, when set, applies binding changes instead of the
+	// overlay hotkeys (e.g. for the evdev backend).
+	onHotkeyChange func(bindings []ihk.Binding)
+
+	// onCancel aborts the dictation in progress; set before Run.
+	onCancel func()
+
+	// onHandsFree turns hands-free dictation on or off and reports whether
+	// it could; nil hides the tray item. Set before Run.
+	onHandsFree func(on bool) bool
+
+	// listMicrophones and useMicrophone back the microphone picker; nil
+	// hides it.
+	listMicrophones func() ([]audio.Device, error)
+	useMicrophone   func(device string) error
 }
 
 // NewManager constructs the Manager.  Call Run() to start the event loop.
@@ -31,6 +54,7 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 		cfg:           cfg,
 		stateChangeCh: make(chan AppState, 16),
 		rmsCh:         make(chan float32, 256),
+		partialCh:     make(chan string, 4),
 		quitCh:        make(chan struct{}),
 	}, nil
 }
@@ -40,13 +64,22 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 func (m *Manager) Run() {
 	// 1. Create the platform overlay (GTK3 on Linux, NSPanel on macOS).
 	m.overlay = newOverlay()
+	if m.hotkeyActions != nil {
+		installOverlayHotkeys(m.overlay, m.hotkeyBindings, m.hotkeyActions)
+	}
 
 	// 2. Create the webview settings window (hidden).
+	m.settingsMu.Lock()
 	m.settings = newSettingsWindow(m)
+	if m.pendingSettings {
+		m.settings.Show()
+	}
+	m.settingsMu.Unlock()
 
 	// 3. Right-click context menu on the overlay (fallback when tray isn't visible).
 	installOverlayContextMenu(m.overlay,
 		func() { m.settings.Show() },
+		m.cancelDictation,
 		func() { m.Quit() },
 	)
 
@@ -75,13 +108,59 @@ func (m *Manager) Quit() {
 	})
 }
 
+// OpenSettings shows the settings window. Safe to call from any goroutine;
+// when called before Run the window opens as soon as the UI is up.
+func (m *Manager) OpenSettings() {
+	m.settingsMu.Lock()
+	defer m.settingsMu.Unlock()
+	if m.settings == nil {
+		m.pendingSettings = true
+		return
+	}
+	m.settings.Show()
+}
+
+// SetOnCancel sets the callback run by the Cancel Dictation item of the tray
+// and overlay menus. Must be called before Run.
+func (m *Manager) SetOnCancel(fn func()) {
+	m.onCancel = fn
+}
+
+// SetOnHandsFree sets the callback run by the Hands-free Dictation item of
+// the tray. fn reports whether the mode could be changed. Must be called
+// before Run.
+func (m *Manager) SetOnHandsFree(fn func(on bool) bool) {
+	m.onHandsFree = fn
+}
+
+// SetMicrophones connects the microphone picker in the Settings window to
+// the capture engine: list returns the capture devices and use switches to
+// one by name or ID. Must be called before Run.
+func (m *Manager) SetMicrophones(list func() ([]audio.Device, error), use func(device string) error) {
+	m.listMicrophones = list
+	m.useMicrophone = use
+}
+
+// cancelDictation runs the cancel callback, if any.
+func (m *Manager) cancelDictation() {
+	if m.onCancel != nil {
+		m.onCancel()
+	}
+}
+
+// toggleHandsFree runs the hands-free callback, if any.
+func (m *Manager) toggleHandsFree(on bool) {
+	if m.onHandsFree != nil {
+		m.onHandsFree(on)
+	}
+}
+
 // --- StateNotifier implementation (compatible with pipeline.StateNotifier) ---
 
 // OnStateChange is called by the pipeline from its own goroutine.
-// The state int maps to AppState: 0=Idle, 1=Recording, 2=Transcribing.
-func (m *Manager) OnStateChange(state int) {
+func (m *Manager) OnStateChange(state AppState) {
 	select {
-	case m.stateChangeCh <- AppState(state):
+	case m.stateChangeCh <- state:
 	default: // drop if channel full (non-blocking)
 	}
 }
@@ -94,6 +173,15 @@ func (m *Manager) OnRMSData(rms float32) {
 	}
 }
 
+// OnPartialTranscript is called by the pipeline's streaming loop while the
+// user is still dictating.
+func (m *Manager) OnPartialTranscript(text string) {
+	select {
+	case m.partialCh <- text:
+	default:
+	}
+}
+
 // processUpdates relays state/RMS messages to the overlay thread-safely.
 func (m *Manager) processUpdates() {
 	for {
@@ -101,29 +189,52 @@ func (m *Manager) processUpdates() {
 		case state := <-m.stateChangeCh:
 			m.overlay.SetState(state)
 			m.updateTrayIcon(state)
+			m.updateTrayCancel(state)
+			m.updateTrayHandsFree(state)
+			if state == StateIdle {
+				m.updateTrayTooltip("")
+			}
 
 		case rms := <-m.rmsCh:
 			m.overlay.PushRMS(rms)
 
+		case text := <-m.partialCh:
+			m.updateTrayTooltip(text)
+
 		case <-m.quitCh:
 			return
 		}
 	}
 }
 
-// InstallHotkey registers a platform hotkey tied to the overlay.
+// InstallHotkeys registers platform hotkeys tied to the overlay, running
+// the callbacks in actions for each binding's action. Hotkeys installed
+// before Run are registered once the overlay exists.
 // Implemented in app_linux.go / app_darwin.go.
-func (m *Manager) InstallHotkey(trigger string, onDown, onUp func()) {
-	m.hotkeyOnDown = onDown
-	m.hotkeyOnUp = onUp
-	installOverlayHotkey(m.overlay, trigger, onDown, onUp)
+func (m *Manager) InstallHotkeys(bindings []ihk.Binding, actions ihk.Actions) {
+	m.hotkeyBindings = bindings
+	m.hotkeyActions = actions
+	if m.overlay != nil {
+		installOverlayHotkeys(m.overlay, bindings, actions)
+	}
+}
+
+// SetOnHotkeyChange sets the callback that applies bindings saved in the
+// Settings window to hotkeys not registered through InstallHotkeys, such as
+// the evdev backend.
+func (m *Manager) SetOnHotkeyChange(fn func(bindings []ihk.Binding)) {
+	m.onHotkeyChange = fn
 }
 
-// reinstallHotkey unregisters the current hotkey and registers a new one with
-// the given trigger string, reusing the original onDown/onUp callbacks.
-func (m *Manager) reinstallHotkey(trigger string) {
-	if m.hotkeyOnDown == nil || m.hotkeyOnUp == nil {
+// reinstallHotkeys replaces the registered hotkeys with bindings, reusing
+// the original actions.
+func (m *Manager) reinstallHotkeys(bindings []ihk.Binding) {
+	if m.onHotkeyChange != nil {
+		m.onHotkeyChange(bindings)
+	}
+	if m.hotkeyActions == nil {
 		return
 	}
-	reinstallOverlayHotkey(m.overlay, trigger, m.hotkeyOnDown, m.hotkeyOnUp)
+	m.hotkeyBindings = bindings
+	reinstallOverlayHotkeys(m.overlay, bindings, m.hotkeyActions)
 }
@@ -7,109 +7,96 @@ import (
 	"time"
 
 	ihk "github.com/cesp99/sussurro/internal/hotkey"
+	"github.com/cesp99/sussurro/internal/hotkey/grab"
 	xhotkey "golang.design/x/hotkey"
 )
 
-// activeHK tracks the currently registered hotkey so it can be unregistered
-// when the user changes the trigger in the settings window.
+// activeHKs tracks the currently registered hotkeys so they can be
+// unregistered when the user changes a binding in the settings window.
 var (
-	activeHK     *xhotkey.Hotkey
+	activeHKs    []*xhotkey.Hotkey
 	activeHKStop chan struct{}
 	activeHKMu   sync.Mutex
 )
 
-// installOverlayHotkey registers the global hotkey on macOS.
+// installOverlayHotkeys registers the global hotkeys on macOS.
 // golang.design/x/hotkey on darwin drives its own CFRunLoop thread, so it can
 // be called from a regular goroutine once [NSApp run] is live.  We wait
 // briefly to guarantee NSApp has started before registering the CGEventTap.
-func installOverlayHotkey(overlay Overlay, trigger string, onDown, onUp func()) {
-	mods, key, err := ihk.ParseTrigger(trigger)
-	if err != nil {
-		return
-	}
-
-	stop := make(chan struct{})
-
+func installOverlayHotkeys(overlay Overlay, bindings []ihk.Binding, actions ihk.Actions) {
 	go func() {
 		// Give [NSApp run] time to initialise before attaching the event tap.
 		time.Sleep(300 * time.Millisecond)
-
-		hk := xhotkey.New(mods, key)
-		if err := hk.Register(); err != nil {
-			return
-		}
-
-		activeHKMu.Lock()
-		activeHK = hk
-		activeHKStop = stop
-		activeHKMu.Unlock()
-
-		for {
-			select {
-			case <-stop:
-				return
-			case <-hk.Keydown():
-				onDown()
-			case <-hk.Keyup():
-				onUp()
-			}
-		}
+		registerHotkeys(bindings, actions)
 	}()
 }
 
-// reinstallOverlayHotkey unregisters the current hotkey and registers a new
-// one with the given trigger, reusing the same onDown/onUp callbacks.
-func reinstallOverlayHotkey(_ Overlay, trigger string, onDown, onUp func()) {
-	// Grab and clear the existing handle under the lock.
+// reinstallOverlayHotkeys unregisters the current hotkeys and registers new
+// ones for bindings, reusing the same actions.
+func reinstallOverlayHotkeys(_ Overlay, bindings []ihk.Binding, actions ihk.Actions) {
+	// Grab and clear the existing handles under the lock.
 	activeHKMu.Lock()
-	old := activeHK
+	old := activeHKs
 	oldStop := activeHKStop
-	activeHK = nil
+	activeHKs = nil
 	activeHKStop = nil
 	activeHKMu.Unlock()
 
-	if old != nil {
-		old.Unregister()
+	for _, hk := range old {
+		hk.Unregister()
 	}
 	if oldStop != nil {
 		close(oldStop)
 	}
 
-	mods, key, err := ihk.ParseTrigger(trigger)
-	if err != nil {
-		return
-	}
-
 	// Brief pause so the OS releases the CGEventTap key grab before we
 	// create a new one for the same (or overlapping) modifier set.
 	time.Sleep(100 * time.Millisecond)
+	registerHotkeys(bindings, actions)
+}
 
+// registerHotkeys registers a hotkey per binding and starts listening.
+// Bindings that fail to parse or register are skipped.
+func registerHotkeys(bindings []ihk.Binding, actions ihk.Actions) {
 	stop := make(chan struct{})
-	hk := xhotkey.New(mods, key)
-	if err := hk.Register(); err != nil {
-		return
+	var hks []*xhotkey.Hotkey
+	for _, b := range bindings {
+		mods, key, err := grab.ParseTrigger(b.Trigger)
+		if err != nil {
+			continue
+		}
+		hk := xhotkey.New(mods, key)
+		if err := hk.Register(); err != nil {
+			continue
+		}
+		hks = append(hks, hk)
+
+		cb := actions[b.Action]
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				case <-hk.Keydown():
+					if cb.Down != nil {
+						cb.Down()
+					}
+				case <-hk.Keyup():
+					if cb.Up != nil {
+						cb.Up()
+					}
+				}
+			}
+		}()
 	}
 
 	activeHKMu.Lock()
-	activeHK = hk
+	activeHKs = hks
 	activeHKStop = stop
 	activeHKMu.Unlock()
-
-	go func() {
-		for {
-			select {
-			case <-stop:
-				return
-			case <-hk.Keydown():
-				onDown()
-			case <-hk.Keyup():
-				onUp()
-			}
-		}
-	}()
 }
 
 // installOverlayContextMenu wires right-click callbacks into the NSPanel overlay.
-func installOverlayContextMenu(overlay Overlay, openSettings, quit func()) {
-	overlaySetContextMenuCallbacks(openSettings, quit)
+func installOverlayContextMenu(overlay Overlay, openSettings, cancel, quit func()) {
+	overlaySetContextMenuCallbacks(openSettings, cancel, quit)
 }
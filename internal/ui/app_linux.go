@@ -4,28 +4,28 @@ package ui
 
 import ihk "github.com/cesp99/sussurro/internal/hotkey"
 
-// installOverlayHotkey registers an X11 global hotkey via GDK XGrabKey.
+// installOverlayHotkeys registers X11 global hotkeys via GDK XGrabKey.
 // On Wayland, the overlay is a *linuxOverlay but IsWayland() returns true,
 // so the caller should skip this and use the trigger server instead.
-func installOverlayHotkey(overlay Overlay, trigger string, onDown, onUp func()) {
+func installOverlayHotkeys(overlay Overlay, bindings []ihk.Binding, actions ihk.Actions) {
 	if lo, ok := overlay.(*linuxOverlay); ok {
-		lo.installHotkey(trigger, onDown, onUp)
+		lo.setHotkeys(bindings, actions)
 	}
 }
 
-// reinstallOverlayHotkey re-registers the X11 hotkey with a new trigger.
-// On Wayland the hotkey is handled by the external trigger server (socket),
+// reinstallOverlayHotkeys replaces the X11 hotkeys with new bindings.
+// On Wayland the hotkeys are handled by the portal or the trigger server,
 // so this is intentionally a no-op in that environment.
-func reinstallOverlayHotkey(overlay Overlay, trigger string, onDown, onUp func()) {
+func reinstallOverlayHotkeys(overlay Overlay, bindings []ihk.Binding, actions ihk.Actions) {
 	if ihk.IsWayland() {
 		return
 	}
-	installOverlayHotkey(overlay, trigger, onDown, onUp)
+	installOverlayHotkeys(overlay, bindings, actions)
 }
 
 // installOverlayContextMenu wires the right-click menu on the GTK3 overlay.
-func installOverlayContextMenu(overlay Overlay, openSettings, quit func()) {
+func installOverlayContextMenu(overlay Overlay, openSettings, cancel, quit func()) {
 	if lo, ok := overlay.(*linuxOverlay); ok {
-		lo.installContextMenu(openSettings, quit)
+		lo.installContextMenu(openSettings, cancel, quit)
 	}
 }
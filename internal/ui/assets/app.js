@@ -29,8 +29,11 @@ function render(data) {
   renderModelList('whisper-list', whisperItems, 'whisper');
   renderModelList('llm-list',     llmItems,     'llm');
 
-  // Hotkey
-  renderHotkey(data.hotkey, data.isWayland);
+  // Microphone
+  renderMicrophones(data.microphones, data.microphone);
+
+  // Hotkeys
+  renderHotkeys(data.bindings, data.isWayland);
 }
 
 // ---- Model list ----
@@ -140,67 +143,170 @@ window.onDownloadError = function(modelId, err) {
   console.error('Download error:', modelId, err);
 };
 
+// ---- Microphone ----
+function renderMicrophones(mics, selected) {
+  const section = document.getElementById('mic-section');
+  const select  = document.getElementById('mic-select');
+  const errorEl = document.getElementById('mic-error');
+  if (!section || !select) return;
+  section.hidden = !mics;
+  if (!mics) return;
+
+  // Rebuilt on every render; onchange is replaced, not stacked
+  select.innerHTML = '';
+  const def = mics.find(m => m.isDefault);
+  const options = [{ id: '', name: def ? `System default (${def.name})` : 'System default' }, ...mics];
+  options.forEach(m => {
+    const opt = document.createElement('option');
+    opt.value = m.id;
+    opt.textContent = m.name;
+    opt.selected = m.id === selected;
+    select.appendChild(opt);
+  });
+
+  select.onchange = async () => {
+    const res = await window.setMicrophone(select.value);
+    if (errorEl) {
+      errorEl.textContent = res.replace(/^error: /, '');
+      errorEl.hidden = !res.startsWith('error');
+    }
+    await reloadSettings();
+  };
+}
+
 // ---- Hotkey ----
-function renderHotkey(trigger, isWayland) {
-  const x11Row     = document.getElementById('hotkey-x11');
+function renderHotkeys(bindings, isWayland) {
+  const list       = document.getElementById('hotkey-list');
   const waylandRow = document.getElementById('hotkey-wayland');
 
   if (isWayland) {
-    if (x11Row)     x11Row.hidden     = true;
+    if (list)       list.hidden       = true;
     if (waylandRow) waylandRow.hidden = false;
     return;
   }
 
   if (waylandRow) waylandRow.hidden = true;
-  if (!x11Row) return;
-  x11Row.hidden = false;
-
-  updateHotkeyDisplay(trigger);
-
-  const editBtn = document.getElementById('hotkey-edit-btn');
-  if (editBtn) editBtn.addEventListener('click', () => showRecordModal(trigger));
+  if (!list) return;
+  list.hidden = false;
+  // Rebuilt on every render, so listeners never pile up
+  list.innerHTML = '';
+
+  bindings.forEach(b => {
+    const row = document.createElement('div');
+    row.className = 'hotkey-row';
+    row.innerHTML = `
+      <div>
+        <div class="toggle-label">${b.label}</div>
+        <div class="hotkey-display" style="margin-top:6px"></div>
+      </div>
+      <div class="hotkey-actions">
+        <button class="hotkey-edit-btn" data-role="change">Change</button>
+        ${b.action === 'dictate' ? '' : '<button class="hotkey-edit-btn" data-role="clear">Clear</button>'}
+      </div>
+    `;
+    updateHotkeyDisplay(row.querySelector('.hotkey-display'), b.trigger);
+
+    row.querySelector('[data-role="change"]').addEventListener('click', () => showRecordModal(b));
+    const clearBtn = row.querySelector('[data-role="clear"]');
+    if (clearBtn) {
+      clearBtn.hidden = b.trigger === '';
+      clearBtn.addEventListener('click', async () => {
+        const res = await window.saveHotkeyBinding(b.action, '');
+        if (!res.startsWith('error')) await reloadSettings();
+      });
+    }
+    list.appendChild(row);
+  });
 }
 
-function updateHotkeyDisplay(trigger) {
-  const display = document.getElementById('hotkey-display');
+function updateHotkeyDisplay(display, trigger) {
   if (!display) return;
-  display.innerHTML = trigger.split('+')
-    .map(k => `<kbd>${k}</kbd>`)
-    .join('<span style="color:var(--muted);font-size:11px;padding:0 2px">+</span>');
+  if (trigger === '') {
+    display.innerHTML = '<span class="hotkey-unset">Not set</span>';
+    return;
+  }
+  // Several chords can be bound to one action in the config file
+  display.innerHTML = trigger.split(', ')
+    .map(chord => chord.split('+')
+      .map(k => `<kbd>${k}</kbd>`)
+      .join('<span style="color:var(--muted);font-size:11px;padding:0 2px">+</span>'))
+    .join('<span style="color:var(--muted);font-size:11px;padding:0 6px">or</span>');
 }
 
 // ---- Record hotkey modal ----
 const MAX_HOTKEY_KEYS = 3;
 const MODIFIER_KEY_NAMES = new Set(['ctrl', 'shift', 'alt', 'super']);
+// A lone key pressed twice within this window records a double tap; it
+// matches the window the evdev backend uses
+const DOUBLE_TAP_MS = 400;
+
+// Sided modifier keys, recorded as such when pressed on their own
+const MODIFIER_CODES = {
+  ControlLeft: 'lctrl', ControlRight: 'rctrl',
+  ShiftLeft:   'lshift', ShiftRight:  'rshift',
+  AltLeft:     'lalt',   AltRight:    'ralt',
+  MetaLeft:    'lsuper', MetaRight:   'rsuper',
+  OSLeft:      'lsuper', OSRight:     'rsuper',
+};
 
-function keyNameFromEvent(e) {
-  switch (e.key) {
-    case 'Control': return 'ctrl';
-    case 'Shift':   return 'shift';
-    case 'Alt':     return 'alt';
-    case 'Meta':    return 'super';
-    default: {
-      const k = e.key.toLowerCase();
-      return k === ' ' ? 'space' : k;
-    }
-  }
+// KeyboardEvent.code values whose trigger name is not derived by keyName
+const CODE_NAMES = {
+  Minus: 'minus', Equal: 'equal', BracketLeft: 'leftbracket', BracketRight: 'rightbracket',
+  Semicolon: 'semicolon', Quote: 'apostrophe', Backquote: 'grave', Backslash: 'backslash',
+  Comma: 'comma', Period: 'period', Slash: 'slash',
+  Space: 'space', Enter: 'enter', Tab: 'tab', Escape: 'escape', Backspace: 'backspace',
+  Delete: 'delete', Insert: 'insert', Home: 'home', End: 'end', PageUp: 'pageup', PageDown: 'pagedown',
+  Pause: 'pause', ScrollLock: 'scrolllock', PrintScreen: 'printscreen', ContextMenu: 'menu', CapsLock: 'capslock',
+  NumpadDecimal: 'kpdot', NumpadDivide: 'kpslash', NumpadMultiply: 'kpasterisk',
+  NumpadSubtract: 'kpminus', NumpadAdd: 'kpplus', NumpadEnter: 'kpenter',
+  MediaPlayPause: 'playpause', MediaStop: 'mediastop', MediaTrackPrevious: 'prevtrack', MediaTrackNext: 'nexttrack',
+  AudioVolumeMute: 'mute', AudioVolumeDown: 'volumedown', AudioVolumeUp: 'volumeup',
+};
+
+// keyName returns the trigger name of the physical key in e, matching the
+// key table in the hotkey package. Modifiers are returned sided.
+function keyName(e) {
+  if (MODIFIER_CODES[e.code]) return MODIFIER_CODES[e.code];
+  if (CODE_NAMES[e.code]) return CODE_NAMES[e.code];
+  let m;
+  if ((m = /^Key([A-Z])$/.exec(e.code)))       return m[1].toLowerCase();
+  if ((m = /^Digit([0-9])$/.exec(e.code)))     return m[1];
+  if ((m = /^Numpad([0-9])$/.exec(e.code)))    return 'kp' + m[1];
+  if ((m = /^(F[0-9]+)$/.exec(e.code)))        return m[1].toLowerCase();
+  if ((m = /^Arrow(\w+)$/.exec(e.code)))       return m[1].toLowerCase();
+  const k = e.key.toLowerCase();
+  return k === ' ' ? 'space' : k;
 }
 
+// modifierOf returns the modifier a sided modifier key holds, or ''.
+function modifierOf(name) {
+  return Object.values(MODIFIER_CODES).includes(name) ? name.slice(1) : '';
+}
+
+// buildTriggerFromSet turns the keys pressed into a trigger. Modifier keys
+// pressed with another key become plain modifiers; a modifier key pressed on
+// its own keeps its side.
 function buildTriggerFromSet(keys) {
-  const mods = [...keys].filter(k =>  MODIFIER_KEY_NAMES.has(k));
-  const main = [...keys].filter(k => !MODIFIER_KEY_NAMES.has(k));
+  const all = [...keys];
+  if (all.length === 1) return all[0];
+  const mods = [...new Set(all.map(modifierOf).filter(Boolean))];
+  const main = all.filter(k => !modifierOf(k));
   return [...mods, ...main].join('+');
 }
 
-function showRecordModal(currentTrigger) {
+function showRecordModal(binding) {
   const modal   = document.getElementById('hotkey-modal');
   const preview = document.getElementById('hotkey-modal-preview');
+  const errorEl = document.getElementById('hotkey-modal-error');
   if (!modal) return;
   modal.classList.add('visible');
+  if (errorEl) errorEl.hidden = true;
 
   const keysHeld = new Set();
   let lastCombo  = '';
   let finalized  = false;
+  let tapTimer   = null; // pending save of a lone key, cancelled by a second tap
+  let tapKey     = '';
 
   function updatePreview() {
     if (!preview) return;
@@ -212,46 +318,85 @@ function showRecordModal(currentTrigger) {
   }
 
   function cleanup() {
+    clearTimeout(tapTimer);
     document.removeEventListener('keydown', downHandler);
     document.removeEventListener('keyup',   upHandler);
   }
 
   function downHandler(e) {
     e.preventDefault();
-    if (finalized) return;
-    const name = keyNameFromEvent(e);
+    if (finalized || e.repeat) return;
+    const name = keyName(e);
+    if (tapTimer !== null) {
+      clearTimeout(tapTimer);
+      tapTimer = null;
+      if (keysHeld.size === 0 && name === tapKey) {
+        // Second tap of a lone key: save it once released
+        lastCombo = 'double+' + name;
+        keysHeld.add(name);
+        if (preview) preview.textContent = lastCombo;
+        return;
+      }
+      lastCombo = '';
+    } else if (lastCombo.startsWith('double+')) {
+      lastCombo = ''; // another key joined the second tap
+    }
     // Cap at MAX_HOTKEY_KEYS — ignore extra keys if already full
     if (keysHeld.size < MAX_HOTKEY_KEYS) keysHeld.add(name);
     updatePreview();
   }
 
-  async function upHandler(e) {
+  async function save(trigger) {
+    finalized = true;
+    const res = await window.saveHotkeyBinding(binding.action, trigger);
+    if (res.startsWith('error')) {
+      // Keep the modal open so another chord can be tried
+      if (errorEl) {
+        errorEl.textContent = res.replace(/^error: /, '');
+        errorEl.hidden = false;
+      }
+      lastCombo = '';
+      finalized = false;
+      updatePreview();
+      return;
+    }
+    cleanup();
+    modal.classList.remove('visible');
+    await reloadSettings();
+  }
+
+  function upHandler(e) {
     e.preventDefault();
     if (finalized) return;
     // Snapshot the full combo on the first key release
     if (lastCombo === '' && keysHeld.size > 0) {
       lastCombo = buildTriggerFromSet(keysHeld);
     }
-    const name = keyNameFromEvent(e);
-    keysHeld.delete(name);
+    keysHeld.delete(keyName(e));
+    if (keysHeld.size > 0 || lastCombo === '') {
+      updatePreview();
+      return;
+    }
+    // All keys are released
+    const parts = lastCombo.split('+');
+    if (parts.every(p => MODIFIER_KEY_NAMES.has(p))) {
+      // Only modifiers were pressed together — reset and keep waiting
+      lastCombo = '';
+      updatePreview();
+      return;
+    }
     updatePreview();
-    // Finalize once all keys are released
-    if (keysHeld.size === 0 && lastCombo !== '') {
-      // Must contain at least one non-modifier key
-      const parts = lastCombo.split('+');
-      const hasMainKey = parts.some(p => !MODIFIER_KEY_NAMES.has(p));
-      if (!hasMainKey) {
-        // Only modifiers were pressed — reset and keep waiting
-        lastCombo = '';
-        updatePreview();
-        return;
-      }
-      finalized = true;
-      cleanup();
-      const res = await window.saveHotkey(lastCombo);
-      modal.classList.remove('visible');
-      if (!res.startsWith('error')) updateHotkeyDisplay(lastCombo);
+    if (parts.length === 1) {
+      // A lone key may be the first tap of a double tap
+      tapKey = lastCombo;
+      const trigger = lastCombo;
+      tapTimer = setTimeout(() => {
+        tapTimer = null;
+        save(trigger);
+      }, DOUBLE_TAP_MS);
+      return;
     }
+    save(lastCombo);
   }
 
   document.addEventListener('keydown', downHandler);
@@ -1,16 +1,20 @@
 package ui
 
+import "github.com/cesp99/sussurro/internal/appstate"
+
 // AppState represents the current state of the application overlay.
-type AppState int
+type AppState = appstate.State
 
 const (
-	StateIdle         AppState = iota // 7 animated dots
-	StateRecording                    // waveform bars
-	StateTranscribing                 // shimmer text
+	StateIdle         = appstate.Idle         // 7 animated dots
+	StateRecording    = appstate.Recording    // waveform bars
+	StateTranscribing = appstate.Transcribing // shimmer text
+	StateListening    = appstate.Listening    // tinted waveform bars (hands-free dictation)
 )
 
 // StateNotifier is the interface called by the pipeline to update UI state.
 type StateNotifier interface {
 	OnStateChange(state AppState)
 	OnRMSData(rms float32)
+	OnPartialTranscript(text string)
 }
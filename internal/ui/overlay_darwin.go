@@ -18,6 +18,7 @@ import "C"
 
 var (
 	contextMenuOpenSettings func()
+	contextMenuCancel       func()
 	contextMenuQuit         func()
 )
 
@@ -28,6 +29,13 @@ func overlayGoOpenSettings() {
 	}
 }
 
+//export overlayGoCancel
+func overlayGoCancel() {
+	if contextMenuCancel != nil {
+		contextMenuCancel()
+	}
+}
+
 //export overlayGoQuit
 func overlayGoQuit() {
 	if contextMenuQuit != nil {
@@ -37,8 +45,9 @@ func overlayGoQuit() {
 
 // overlaySetContextMenuCallbacks stores the Go callbacks and signals ObjC that
 // right-click context menu is active.
-func overlaySetContextMenuCallbacks(openSettings, quit func()) {
+func overlaySetContextMenuCallbacks(openSettings, cancel, quit func()) {
 	contextMenuOpenSettings = openSettings
+	contextMenuCancel = cancel
 	contextMenuQuit = quit
 	C.overlay_set_context_menu_callbacks_macos()
 }
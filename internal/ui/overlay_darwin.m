@@ -5,6 +5,7 @@
 
 /* Exported Go callbacks — defined by CGo in overlay_darwin.go */
 extern void overlayGoOpenSettings(void);
+extern void overlayGoCancel(void);
 extern void overlayGoQuit(void);
 
 static BOOL g_context_menu_enabled = NO;
@@ -13,6 +14,7 @@ static BOOL g_context_menu_enabled = NO;
 #define OVERLAY_STATE_IDLE          0
 #define OVERLAY_STATE_RECORDING     1
 #define OVERLAY_STATE_TRANSCRIBING  2
+#define OVERLAY_STATE_LISTENING     3
 
 #define ITEM_COUNT     7
 #define BAR_MIN_HEIGHT 4.0
@@ -104,6 +106,12 @@ static CVReturn displayLinkCallback(CVDisplayLinkRef link,
 {
     if (!g_context_menu_enabled) return;
     NSMenu *menu = [[NSMenu alloc] initWithTitle:@""];
+    /* Only offered while there is a dictation to cancel */
+    if (state != OVERLAY_STATE_IDLE) {
+        [menu addItemWithTitle:@"Cancel Dictation"
+                       action:@selector(menuCancel)
+                keyEquivalent:@""];
+    }
     [menu addItemWithTitle:@"Open Settings"
                    action:@selector(menuOpenSettings)
             keyEquivalent:@""];
@@ -120,6 +128,7 @@ static CVReturn displayLinkCallback(CVDisplayLinkRef link,
 }
 
 - (void)menuOpenSettings { overlayGoOpenSettings(); }
+- (void)menuCancel       { overlayGoCancel(); }
 - (void)menuQuit         { overlayGoQuit(); }
 
 - (void)drawRect:(NSRect)dirtyRect
@@ -168,7 +177,8 @@ static CVReturn displayLinkCallback(CVDisplayLinkRef link,
 
     switch (state) {
     case OVERLAY_STATE_IDLE:   [self drawDots:ctx w:w h:h]; break;
-    case OVERLAY_STATE_RECORDING:  [self drawBars:ctx w:w h:h]; break;
+    case OVERLAY_STATE_RECORDING:
+    case OVERLAY_STATE_LISTENING:  [self drawBars:ctx w:w h:h]; break;
     case OVERLAY_STATE_TRANSCRIBING: [self drawShimmer:ctx w:w h:h]; break;
     }
 }
@@ -198,7 +208,11 @@ static CVReturn displayLinkCallback(CVDisplayLinkRef link,
     double startX  = (w - totalW) / 2.0;
     double cy      = h / 2.0;
 
-    CGContextSetRGBFillColor(ctx, 1, 1, 1, 1);
+    /* Tinted while hands-free dictation listens, white while recording */
+    if (state == OVERLAY_STATE_LISTENING)
+        CGContextSetRGBFillColor(ctx, 0.35, 0.85, 0.80, 1);
+    else
+        CGContextSetRGBFillColor(ctx, 1, 1, 1, 1);
     for (int i = 0; i < ITEM_COUNT; i++) {
         double bh = barHeights[i];
         double cx = startX + i * spacing;
@@ -24,12 +24,14 @@ struct OverlayData {
     /* Shimmer phase for transcribing text */
     double       shimmer_phase;
 
-    /* X11 hotkey */
+    /* X11 hotkeys */
     HotkeyDownCB down_cb;
     HotkeyUpCB   up_cb;
-    int          hk_keycode;
-    unsigned int hk_mods;
-    gboolean     hk_pressed;
+    int          hk_count;
+    int          hk_keycode[OVERLAY_MAX_HOTKEYS];
+    unsigned int hk_mods[OVERLAY_MAX_HOTKEYS];
+    gboolean     hk_pressed[OVERLAY_MAX_HOTKEYS];
+    gboolean     hk_filter_installed;
 };
 
 /* ------------------------------------------------------------------ */
@@ -91,7 +93,11 @@ static void draw_recording_bars(cairo_t *cr, OverlayData *od)
     double start_x = (OVERLAY_WIDTH - total_w) / 2.0;
     double center_y = OVERLAY_HEIGHT / 2.0;
 
-    cairo_set_source_rgba(cr, 1.0, 1.0, 1.0, 1.0);
+    /* Tinted while hands-free dictation listens, white while recording */
+    if (od->state == OVERLAY_STATE_LISTENING)
+        cairo_set_source_rgba(cr, LISTEN_R, LISTEN_G, LISTEN_B, 1.0);
+    else
+        cairo_set_source_rgba(cr, 1.0, 1.0, 1.0, 1.0);
 
     for (int i = 0; i < ITEM_COUNT; i++) {
         double h  = od->bar_heights[i];
@@ -176,6 +182,7 @@ static gboolean on_draw(GtkWidget *widget, cairo_t *cr, gpointer data)
         draw_idle_dots(cr, od);
         break;
     case OVERLAY_STATE_RECORDING:
+    case OVERLAY_STATE_LISTENING:
         draw_recording_bars(cr, od);
         break;
     case OVERLAY_STATE_TRANSCRIBING:
@@ -218,67 +225,35 @@ static GdkFilterReturn x11_event_filter(GdkXEvent *xevent, GdkEvent *event, gpoi
     OverlayData *od = (OverlayData *)data;
     XEvent *xe = (XEvent *)xevent;
 
+    /* Compare modifiers exactly, so ctrl+space and ctrl+shift+space can be
+       bound to different actions. Lock keys are ignored. */
+    unsigned int relevant = ControlMask | ShiftMask | Mod1Mask | Mod4Mask;
+    gboolean matched = FALSE;
+
     if (xe->type == KeyPress) {
-        if ((int)xe->xkey.keycode == od->hk_keycode &&
-            (xe->xkey.state & od->hk_mods) == od->hk_mods) {
-            if (!od->hk_pressed) {
-                od->hk_pressed = TRUE;
-                if (od->down_cb) od->down_cb();
+        for (int i = 0; i < od->hk_count; i++) {
+            if ((int)xe->xkey.keycode == od->hk_keycode[i] &&
+                (xe->xkey.state & relevant) == od->hk_mods[i]) {
+                matched = TRUE;
+                if (!od->hk_pressed[i]) {
+                    od->hk_pressed[i] = TRUE;
+                    if (od->down_cb) od->down_cb(i);
+                }
             }
-            return GDK_FILTER_REMOVE;
         }
     } else if (xe->type == KeyRelease) {
-        if ((int)xe->xkey.keycode == od->hk_keycode) {
-            if (od->hk_pressed) {
-                od->hk_pressed = FALSE;
-                if (od->up_cb) od->up_cb();
+        for (int i = 0; i < od->hk_count; i++) {
+            if ((int)xe->xkey.keycode == od->hk_keycode[i]) {
+                matched = TRUE;
+                if (od->hk_pressed[i]) {
+                    od->hk_pressed[i] = FALSE;
+                    if (od->up_cb) od->up_cb(i);
+                }
             }
-            return GDK_FILTER_REMOVE;
         }
     }
 
-    return GDK_FILTER_CONTINUE;
-}
-
-static unsigned int parse_x11_mods(const char *trigger)
-{
-    unsigned int mods = 0;
-    char *copy = strdup(trigger);
-    char *tok  = strtok(copy, "+");
-    while (tok) {
-        if      (strcmp(tok, "ctrl")  == 0) mods |= ControlMask;
-        else if (strcmp(tok, "shift") == 0) mods |= ShiftMask;
-        else if (strcmp(tok, "alt")   == 0) mods |= Mod1Mask;
-        else if (strcmp(tok, "super") == 0) mods |= Mod4Mask;
-        tok = strtok(NULL, "+");
-    }
-    free(copy);
-    return mods;
-}
-
-static KeySym parse_x11_keysym(const char *trigger)
-{
-    /* Last token after splitting on '+' */
-    const char *p = strrchr(trigger, '+');
-    const char *key_str = p ? p + 1 : trigger;
-
-    if (strcmp(key_str, "space") == 0) return XK_space;
-    if (strcmp(key_str, "enter") == 0) return XK_Return;
-    if (strcmp(key_str, "tab")   == 0) return XK_Tab;
-
-    /* Single character keys */
-    if (strlen(key_str) == 1) {
-        char buf[2] = {key_str[0], 0};
-        return XStringToKeysym(buf);
-    }
-
-    /* F-keys */
-    if (key_str[0] == 'f' || key_str[0] == 'F') {
-        int n = atoi(key_str + 1);
-        if (n >= 1 && n <= 12) return XK_F1 + (n - 1);
-    }
-
-    return XStringToKeysym(key_str);
+    return matched ? GDK_FILTER_REMOVE : GDK_FILTER_CONTINUE;
 }
 
 #endif /* WAYLAND_ONLY */
@@ -382,45 +357,85 @@ GtkWidget *overlay_create(void)
     return win;
 }
 
-void overlay_install_hotkey(GtkWidget *win, const char *trigger,
-                            HotkeyDownCB down_cb, HotkeyUpCB up_cb)
-{
-    OverlayData *od = (OverlayData *)g_object_get_data(G_OBJECT(win), "overlay-data");
-    if (!od) return;
+typedef struct {
+    GtkWidget    *win;
+    unsigned long keysyms[OVERLAY_MAX_HOTKEYS];
+    unsigned int  mods[OVERLAY_MAX_HOTKEYS];
+    int           count;
+    HotkeyDownCB  down_cb;
+    HotkeyUpCB    up_cb;
+} IdleHotkeysArg;
 
-    od->down_cb = down_cb;
-    od->up_cb   = up_cb;
+/* Lock-key combinations each hotkey is grabbed with */
+static const unsigned int lock_combos[] = {0, LockMask, Mod2Mask, LockMask | Mod2Mask};
+
+static gboolean idle_set_hotkeys(gpointer data)
+{
+    IdleHotkeysArg *arg = (IdleHotkeysArg *)data;
+    OverlayData *od = (OverlayData *)g_object_get_data(G_OBJECT(arg->win), "overlay-data");
 
 #ifndef WAYLAND_ONLY
     GdkDisplay *display = gdk_display_get_default();
 
     /* Only install on X11 displays */
-    if (!GDK_IS_X11_DISPLAY(display)) return;
-
-    Display *xdpy  = gdk_x11_display_get_xdisplay(display);
-    Window   xroot = DefaultRootWindow(xdpy);
-
-    unsigned int mods    = parse_x11_mods(trigger);
-    KeySym       keysym  = parse_x11_keysym(trigger);
-    int          keycode = XKeysymToKeycode(xdpy, keysym);
+    if (od && GDK_IS_X11_DISPLAY(display)) {
+        Display *xdpy  = gdk_x11_display_get_xdisplay(display);
+        Window   xroot = DefaultRootWindow(xdpy);
+
+        /* Release the previous grabs */
+        for (int i = 0; i < od->hk_count; i++) {
+            for (int j = 0; j < 4 && od->hk_keycode[i] != 0; j++) {
+                XUngrabKey(xdpy, od->hk_keycode[i], od->hk_mods[i] | lock_combos[j], xroot);
+            }
+            od->hk_pressed[i] = FALSE;
+        }
 
-    od->hk_keycode = keycode;
-    od->hk_mods    = mods;
+        od->down_cb  = arg->down_cb;
+        od->up_cb    = arg->up_cb;
+        od->hk_count = arg->count;
+        for (int i = 0; i < arg->count; i++) {
+            od->hk_mods[i]    = arg->mods[i];
+            od->hk_keycode[i] = XKeysymToKeycode(xdpy, (KeySym)arg->keysyms[i]);
+            /* Keycode 0 is AnyKey; never grab the whole keyboard */
+            for (int j = 0; j < 4 && od->hk_keycode[i] != 0; j++) {
+                XGrabKey(xdpy, od->hk_keycode[i], od->hk_mods[i] | lock_combos[j],
+                         xroot, True, GrabModeAsync, GrabModeAsync);
+            }
+        }
 
-    /* Grab with all lock-key combinations */
-    unsigned int lock_combos[] = {0, LockMask, Mod2Mask, LockMask | Mod2Mask};
-    for (int i = 0; i < 4; i++) {
-        XGrabKey(xdpy, keycode, mods | lock_combos[i],
-                 xroot, True, GrabModeAsync, GrabModeAsync);
+        /* Install GDK event filter on root window once */
+        if (!od->hk_filter_installed) {
+            GdkWindow *root_gdk = gdk_x11_window_foreign_new_for_display(display, xroot);
+            if (root_gdk) {
+                gdk_window_add_filter(root_gdk, x11_event_filter, od);
+                g_object_unref(root_gdk);
+                od->hk_filter_installed = TRUE;
+            }
+        }
     }
+#else
+    (void)od;
+#endif
+
+    g_free(arg);
+    return G_SOURCE_REMOVE;
+}
 
-    /* Install GDK event filter on root window */
-    GdkWindow *root_gdk = gdk_x11_window_foreign_new_for_display(display, xroot);
-    if (root_gdk) {
-        gdk_window_add_filter(root_gdk, x11_event_filter, od);
-        g_object_unref(root_gdk);
+void overlay_set_hotkeys(GtkWidget *win, const unsigned long *keysyms,
+                         const unsigned int *mods, int count,
+                         HotkeyDownCB down_cb, HotkeyUpCB up_cb)
+{
+    IdleHotkeysArg *arg = g_new0(IdleHotkeysArg, 1);
+    arg->win     = win;
+    arg->down_cb = down_cb;
+    arg->up_cb   = up_cb;
+    if (count > OVERLAY_MAX_HOTKEYS) count = OVERLAY_MAX_HOTKEYS;
+    for (int i = 0; i < count; i++) {
+        arg->keysyms[i] = keysyms[i];
+        arg->mods[i]    = mods[i];
     }
-#endif
+    arg->count = count;
+    gdk_threads_add_idle(idle_set_hotkeys, arg);
 }
 
 /* ---- Async state/RMS update ---- */
@@ -480,6 +495,7 @@ void overlay_push_rms_async(GtkWidget *win, float rms)
 /* ------------------------------------------------------------------ */
 
 static MenuOpenSettingsCB g_open_settings_cb = NULL;
+static MenuCancelCB       g_cancel_cb        = NULL;
 static MenuQuitCB         g_quit_cb          = NULL;
 
 static void on_menu_open_settings(GtkMenuItem *item, gpointer data)
@@ -488,6 +504,12 @@ static void on_menu_open_settings(GtkMenuItem *item, gpointer data)
     if (g_open_settings_cb) g_open_settings_cb();
 }
 
+static void on_menu_cancel(GtkMenuItem *item, gpointer data)
+{
+    (void)item; (void)data;
+    if (g_cancel_cb) g_cancel_cb();
+}
+
 static void on_menu_quit(GtkMenuItem *item, gpointer data)
 {
     (void)item; (void)data;
@@ -496,8 +518,9 @@ static void on_menu_quit(GtkMenuItem *item, gpointer data)
 
 static gboolean on_button_press(GtkWidget *widget, GdkEventButton *event, gpointer data)
 {
-    (void)widget; (void)data;
+    (void)data;
     if (event->type == GDK_BUTTON_PRESS && event->button == 3) {
+        OverlayData *od      = (OverlayData *)g_object_get_data(G_OBJECT(widget), "overlay-data");
         GtkWidget *menu      = gtk_menu_new();
         GtkWidget *i_settings = gtk_menu_item_new_with_label("Open Settings");
         GtkWidget *i_sep     = gtk_separator_menu_item_new();
@@ -508,6 +531,13 @@ static gboolean on_button_press(GtkWidget *widget, GdkEventButton *event, gpoint
         g_signal_connect(i_quit,     "activate",
                          G_CALLBACK(on_menu_quit), NULL);
 
+        /* Only offered while there is a dictation to cancel */
+        if (od && od->state != OVERLAY_STATE_IDLE) {
+            GtkWidget *i_cancel = gtk_menu_item_new_with_label("Cancel Dictation");
+            g_signal_connect(i_cancel, "activate",
+                             G_CALLBACK(on_menu_cancel), NULL);
+            gtk_menu_shell_append(GTK_MENU_SHELL(menu), i_cancel);
+        }
         gtk_menu_shell_append(GTK_MENU_SHELL(menu), i_settings);
         gtk_menu_shell_append(GTK_MENU_SHELL(menu), i_sep);
         gtk_menu_shell_append(GTK_MENU_SHELL(menu), i_quit);
@@ -521,9 +551,11 @@ static gboolean on_button_press(GtkWidget *widget, GdkEventButton *event, gpoint
 
 void overlay_install_context_menu(GtkWidget *win,
                                   MenuOpenSettingsCB open_settings_cb,
+                                  MenuCancelCB cancel_cb,
                                   MenuQuitCB quit_cb)
 {
     g_open_settings_cb = open_settings_cb;
+    g_cancel_cb        = cancel_cb;
     g_quit_cb          = quit_cb;
 
     gtk_widget_add_events(win, GDK_BUTTON_PRESS_MASK);
@@ -9,21 +9,27 @@ package ui
 #include "overlay_linux.h"
 
 // Forward-declare the Go-exported trampolines so C can call them.
-extern void goHotkeyDown(void);
-extern void goHotkeyUp(void);
+extern void goHotkeyDown(int id);
+extern void goHotkeyUp(int id);
 extern void goOpenSettings(void);
+extern void goCancel(void);
 extern void goQuit(void);
 
 // Static helpers return function pointers for the trampolines.
 static HotkeyDownCB      hotkeyDownCB(void)      { return (HotkeyDownCB)goHotkeyDown;           }
 static HotkeyUpCB        hotkeyUpCB(void)        { return (HotkeyUpCB)goHotkeyUp;               }
 static MenuOpenSettingsCB menuOpenSettingsCB(void) { return (MenuOpenSettingsCB)goOpenSettings;   }
+static MenuCancelCB       menuCancelCB(void)       { return (MenuCancelCB)goCancel;               }
 static MenuQuitCB         menuQuitCB(void)         { return (MenuQuitCB)goQuit;                   }
 */
 import "C"
 import (
+	"log/slog"
 	"os"
+	"sync"
 	"unsafe"
+
+	ihk "github.com/cesp99/sussurro/internal/hotkey"
 )
 
 // linuxOverlay wraps the CGO GTK3 overlay window.
@@ -33,23 +39,36 @@ type linuxOverlay struct {
 
 // Singleton callbacks — only one overlay per process.
 var (
-	globalDownCB        func()
-	globalUpCB          func()
 	globalOpenSettingsCB func()
-	globalQuitCB        func()
+	globalCancelCB       func()
+	globalQuitCB         func()
+
+	// globalHotkeys holds the callbacks of each grabbed hotkey, by index.
+	globalHotkeysMu sync.Mutex
+	globalHotkeys   []ihk.Callbacks
 )
 
+// hotkeyCallbacks returns the callbacks of the hotkey at index id.
+func hotkeyCallbacks(id C.int) ihk.Callbacks {
+	globalHotkeysMu.Lock()
+	defer globalHotkeysMu.Unlock()
+	if int(id) < 0 || int(id) >= len(globalHotkeys) {
+		return ihk.Callbacks{}
+	}
+	return globalHotkeys[id]
+}
+
 //export goHotkeyDown
-func goHotkeyDown() {
-	if globalDownCB != nil {
-		globalDownCB()
+func goHotkeyDown(id C.int) {
+	if cb := hotkeyCallbacks(id).Down; cb != nil {
+		cb()
 	}
 }
 
 //export goHotkeyUp
-func goHotkeyUp() {
-	if globalUpCB != nil {
-		globalUpCB()
+func goHotkeyUp(id C.int) {
+	if cb := hotkeyCallbacks(id).Up; cb != nil {
+		cb()
 	}
 }
 
@@ -60,6 +79,13 @@ func goOpenSettings() {
 	}
 }
 
+//export goCancel
+func goCancel() {
+	if globalCancelCB != nil {
+		globalCancelCB()
+	}
+}
+
 //export goQuit
 func goQuit() {
 	if globalQuitCB != nil {
@@ -83,15 +109,45 @@ func newOverlay() Overlay {
 	return &linuxOverlay{win: unsafe.Pointer(win)}
 }
 
-// installHotkey registers an X11 global hotkey (no-op on Wayland).
-func (o *linuxOverlay) installHotkey(trigger string, onDown, onUp func()) {
-	globalDownCB = onDown
-	globalUpCB = onUp
-	ctrig := C.CString(trigger)
-	defer C.free(unsafe.Pointer(ctrig))
-	C.overlay_install_hotkey(
+// setHotkeys replaces the X11 global hotkeys (no-op on Wayland). Keys are
+// resolved through the hotkey package's key table, so the grab matches the
+// keys the other backends use. Modifier-only and double-tap bindings cannot
+// be grabbed and are left to the evdev handler.
+func (o *linuxOverlay) setHotkeys(bindings []ihk.Binding, actions ihk.Actions) {
+	chords, _ := ihk.SplitBindings(bindings)
+
+	var (
+		callbacks []ihk.Callbacks
+		keysyms   []C.ulong
+		mods      []C.uint
+	)
+	for _, b := range chords {
+		if len(keysyms) == C.OVERLAY_MAX_HOTKEYS {
+			slog.Warn("Too many hotkeys for the overlay grab", "max", int(C.OVERLAY_MAX_HOTKEYS))
+			break
+		}
+		keysym, mask, err := ihk.X11Key(b.Trigger)
+		if err != nil {
+			slog.Warn("Cannot grab hotkey", "trigger", b.Trigger, "error", err)
+			continue
+		}
+		callbacks = append(callbacks, actions[b.Action])
+		keysyms = append(keysyms, C.ulong(keysym))
+		mods = append(mods, C.uint(mask))
+	}
+
+	globalHotkeysMu.Lock()
+	globalHotkeys = callbacks
+	globalHotkeysMu.Unlock()
+
+	// Keep the arrays addressable when there are no hotkeys
+	keysyms = append(keysyms, 0)
+	mods = append(mods, 0)
+	C.overlay_set_hotkeys(
 		(*C.GtkWidget)(o.win),
-		ctrig,
+		&keysyms[0],
+		&mods[0],
+		C.int(len(callbacks)),
 		C.hotkeyDownCB(),
 		C.hotkeyUpCB(),
 	)
@@ -118,12 +174,14 @@ func (o *linuxOverlay) Close() {
 }
 
 // installContextMenu wires the right-click popup on the overlay window.
-func (o *linuxOverlay) installContextMenu(openSettings, quit func()) {
+func (o *linuxOverlay) installContextMenu(openSettings, cancel, quit func()) {
 	globalOpenSettingsCB = openSettings
+	globalCancelCB = cancel
 	globalQuitCB = quit
 	C.overlay_install_context_menu(
 		(*C.GtkWidget)(o.win),
 		C.menuOpenSettingsCB(),
+		C.menuCancelCB(),
 		C.menuQuitCB(),
 	)
 }
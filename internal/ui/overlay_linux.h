@@ -22,6 +22,7 @@
 #define OVERLAY_STATE_IDLE          0
 #define OVERLAY_STATE_RECORDING     1
 #define OVERLAY_STATE_TRANSCRIBING  2
+#define OVERLAY_STATE_LISTENING     3
 
 /* ---- Geometry ---- */
 #define OVERLAY_WIDTH    220
@@ -43,14 +44,20 @@
 #define BAR_MAX_HEIGHT 40.0
 #define RMS_SCALE       0.08
 
+/* ---- Hands-free listening bar color (teal) ---- */
+#define LISTEN_R  0.35
+#define LISTEN_G  0.85
+#define LISTEN_B  0.80
+
 /* ---- Dot parameters ---- */
 #define DOT_RADIUS   3.0
 #define DOT_SPACING 10.0
 
 /* ---- Callback types ---- */
-typedef void (*HotkeyDownCB)(void);
-typedef void (*HotkeyUpCB)(void);
+typedef void (*HotkeyDownCB)(int id);
+typedef void (*HotkeyUpCB)(int id);
 typedef void (*MenuOpenSettingsCB)(void);
+typedef void (*MenuCancelCB)(void);
 typedef void (*MenuQuitCB)(void);
 
 /* Opaque overlay data */
@@ -72,9 +79,14 @@ typedef struct {
 /* Create the overlay window (layer-shell if possible, else always-on-top fallback) */
 GtkWidget *overlay_create(void);
 
-/* Install X11 global hotkey bound to the overlay (no-op on Wayland) */
-void overlay_install_hotkey(GtkWidget *win, const char *trigger,
-                            HotkeyDownCB down_cb, HotkeyUpCB up_cb);
+/* Replace the X11 global hotkeys bound to the overlay (no-op on Wayland).
+   Hotkey i is keysyms[i] with the modifier mask mods[i]; the callbacks
+   receive i. The grabs are changed on the GTK main loop, so this may be
+   called from any thread. */
+#define OVERLAY_MAX_HOTKEYS 16
+void overlay_set_hotkeys(GtkWidget *win, const unsigned long *keysyms,
+                         const unsigned int *mods, int count,
+                         HotkeyDownCB down_cb, HotkeyUpCB up_cb);
 
 /* Thread-safe async state/RMS updates via gdk_threads_add_idle */
 void overlay_set_state_async(GtkWidget *win, int state);
@@ -84,9 +96,12 @@ void overlay_push_rms_async(GtkWidget *win, float rms);
 gboolean idle_set_state(gpointer data);
 gboolean idle_push_rms(gpointer data);
 
-/* Right-click context menu (fallback for when no system tray is visible) */
+/* Right-click context menu (fallback for when no system tray is visible).
+   Cancel Dictation is only listed while recording, transcribing, or
+   listening hands-free. */
 void overlay_install_context_menu(GtkWidget *win,
                                   MenuOpenSettingsCB open_settings_cb,
+                                  MenuCancelCB cancel_cb,
                                   MenuQuitCB quit_cb);
 
 /* Show / hide */
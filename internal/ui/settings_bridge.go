@@ -7,8 +7,11 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 
+	"github.com/cesp99/sussurro/internal/audio"
 	"github.com/cesp99/sussurro/internal/config"
+	ihk "github.com/cesp99/sussurro/internal/hotkey"
 	"github.com/cesp99/sussurro/internal/setup"
 	"github.com/cesp99/sussurro/internal/version"
 )
@@ -24,13 +27,30 @@ type modelInfo struct {
 	Type        string `json:"type"` // "whisper" or "llm"
 }
 
+// hotkeyInfo describes the hotkey of an action for the settings UI.
+type hotkeyInfo struct {
+	Action  string `json:"action"`
+	Label   string `json:"label"`
+	Trigger string `json:"trigger"` // "" when unbound
+}
+
+// microphoneInfo describes a capture device for the settings UI.
+type microphoneInfo struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"isDefault"`
+}
+
 // initialData is returned by getInitialData().
 type initialData struct {
-	Platform  string      `json:"platform"`
-	Version   string      `json:"version"`
-	Models    []modelInfo `json:"models"`
-	Hotkey    string      `json:"hotkey"`
-	IsWayland bool        `json:"isWayland"`
+	Platform    string           `json:"platform"`
+	Version     string           `json:"version"`
+	Models      []modelInfo      `json:"models"`
+	Hotkey      string           `json:"hotkey"`
+	Bindings    []hotkeyInfo     `json:"bindings"`
+	IsWayland   bool             `json:"isWayland"`
+	Microphones []microphoneInfo `json:"microphones"` // nil hides the picker
+	Microphone  string           `json:"microphone"`  // ID of the selected device, "" for the default
 }
 
 // bindBridge attaches all Go↔JS bridge functions to the webview.
@@ -49,20 +69,50 @@ func bindBridge(sw *settingsWindow) {
 		return string(b)
 	})
 
-	sw.w.Bind("saveHotkey", func(trigger string) (result string) {
+	sw.w.Bind("saveHotkeyBinding", func(action, trigger string) (result string) {
 		defer func() {
 			if r := recover(); r != nil {
-				slog.Error("panic in saveHotkey", "error", r)
+				slog.Error("panic in saveHotkeyBinding", "error", r)
 				result = fmt.Sprintf("error: panic: %v", r)
 			}
 		}()
-		if err := config.SaveHotkey(mgr.cfg, trigger); err != nil {
+		hk, bindings, err := rebindAction(mgr.cfg.Hotkey, action, trigger)
+		if err != nil {
+			return fmt.Sprintf("error: %v", err)
+		}
+		if action == ihk.ActionDictate {
+			err = config.SaveHotkey(mgr.cfg, hk.Trigger)
+		} else {
+			err = config.SaveBindings(mgr.cfg, hk.Bindings)
+		}
+		if err != nil {
 			return fmt.Sprintf("error: %v", err)
 		}
-		mgr.cfg.Hotkey.Trigger = trigger
-		// Re-register the OS-level hotkey with the new trigger so it takes
-		// effect immediately without requiring a restart.
-		go mgr.reinstallHotkey(trigger)
+		mgr.cfg.Hotkey = hk
+		// Re-register the OS-level hotkeys with the new bindings so they
+		// take effect immediately without requiring a restart.
+		go mgr.reinstallHotkeys(bindings)
+		return "ok"
+	})
+
+	sw.w.Bind("setMicrophone", func(id string) (result string) {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("panic in setMicrophone", "error", r)
+				result = fmt.Sprintf("error: panic: %v", r)
+			}
+		}()
+		if mgr.useMicrophone == nil {
+			return "error: microphone selection is unavailable"
+		}
+		// Switch first, so a device that vanished is not saved
+		if err := mgr.useMicrophone(id); err != nil {
+			return fmt.Sprintf("error: %v", err)
+		}
+		if err := config.SaveAudioDevice(mgr.cfg, id); err != nil {
+			return fmt.Sprintf("error: %v", err)
+		}
+		mgr.cfg.Audio.Device = id
 		return "ok"
 	})
 
@@ -196,13 +246,104 @@ func buildInitialData(mgr *Manager) initialData {
 		platform += " (X11)"
 	}
 
+	// The evdev backend works on Wayland too, and rebinds from the editor
+	backend, _ := ihk.ParseBackend(mgr.cfg.Hotkey.Backend)
+
+	mics, mic := microphoneInfos(mgr)
+
 	return initialData{
-		Platform:  platform,
-		Version:   version.Version,
-		Models:    models,
-		Hotkey:    mgr.cfg.Hotkey.Trigger,
-		IsWayland: isWayland,
+		Platform:    platform,
+		Version:     version.Version,
+		Models:      models,
+		Hotkey:      mgr.cfg.Hotkey.Trigger,
+		Bindings:    hotkeyInfos(mgr.cfg.Hotkey),
+		IsWayland:   isWayland && backend != ihk.BackendEvdev,
+		Microphones: mics,
+		Microphone:  mic,
+	}
+}
+
+// microphoneInfos lists the capture devices and the ID of the one
+// audio.device selects, or "" when it selects the default or a device that
+// is not connected.
+func microphoneInfos(mgr *Manager) ([]microphoneInfo, string) {
+	if mgr.listMicrophones == nil {
+		return nil, ""
+	}
+	devices, err := mgr.listMicrophones()
+	if err != nil {
+		slog.Warn("Cannot list microphones", "error", err)
+		return nil, ""
+	}
+	mics := make([]microphoneInfo, 0, len(devices))
+	for _, d := range devices {
+		mics = append(mics, microphoneInfo{ID: d.ID, Name: d.Name, IsDefault: d.IsDefault})
+	}
+	selected := ""
+	if !audio.IsDefaultDevice(mgr.cfg.Audio.Device) {
+		if d, err := audio.FindDevice(devices, mgr.cfg.Audio.Device); err == nil {
+			selected = d.ID
+		}
+	}
+	return mics, selected
+}
+
+// rebindAction returns hk with action bound to trigger alone, and the
+// resulting bindings. An empty trigger unbinds the action; dictate must keep
+// a trigger. Chords already bound to another action are rejected.
+func rebindAction(hk config.HotkeyConfig, action, trigger string) (config.HotkeyConfig, []ihk.Binding, error) {
+	if trigger != "" {
+		t, err := ihk.NormalizeTrigger(trigger)
+		if err != nil {
+			return hk, nil, err
+		}
+		trigger = t
+	}
+
+	if action == ihk.ActionDictate {
+		if trigger == "" {
+			return hk, nil, fmt.Errorf("dictation needs a hotkey")
+		}
+		hk.Trigger = trigger
+	} else {
+		// Copy so the live config is only changed once the file is saved
+		extra := make([]config.BindingConfig, 0, len(hk.Bindings)+1)
+		for _, b := range hk.Bindings {
+			if b.Action != action {
+				extra = append(extra, b)
+			}
+		}
+		if trigger != "" {
+			extra = append(extra, config.BindingConfig{Trigger: trigger, Action: action})
+		}
+		hk.Bindings = extra
+	}
+
+	bindings, err := ihk.ConfigBindings(hk)
+	if err != nil {
+		return hk, nil, err
+	}
+	return hk, bindings, nil
+}
+
+// hotkeyInfos lists every action with its triggers, for the hotkey editor.
+func hotkeyInfos(hk config.HotkeyConfig) []hotkeyInfo {
+	triggers := make(map[string][]string)
+	triggers[ihk.ActionDictate] = []string{hk.Trigger}
+	for _, b := range hk.Bindings {
+		action := strings.ToLower(strings.TrimSpace(b.Action))
+		triggers[action] = append(triggers[action], b.Trigger)
+	}
+
+	infos := make([]hotkeyInfo, 0, len(ihk.ActionNames()))
+	for _, action := range ihk.ActionNames() {
+		infos = append(infos, hotkeyInfo{
+			Action:  action,
+			Label:   ihk.ActionLabel(action),
+			Trigger: strings.Join(triggers[action], ", "),
+		})
 	}
+	return infos
 }
 
 func fileExists(path string) bool {
@@ -2,6 +2,8 @@ package ui
 
 import (
 	_ "embed"
+	"strings"
+	"sync/atomic"
 
 	"github.com/getlantern/systray"
 )
@@ -12,6 +14,12 @@ var trayIcon []byte
 //go:embed assets/tray_rec.png
 var trayIconRec []byte
 
+// trayCancel is the Cancel Dictation item, set once the tray is ready.
+var trayCancel atomic.Pointer[systray.MenuItem]
+
+// trayHandsFree is the Hands-free Dictation item, set once the tray is ready.
+var trayHandsFree atomic.Pointer[systray.MenuItem]
+
 // runTray starts the system tray in the calling goroutine (blocks).
 // It must be started with go m.runTray() so it doesn't block the UI thread.
 func (m *Manager) runTray() {
@@ -22,6 +30,14 @@ func (m *Manager) onTrayReady() {
 	systray.SetIcon(trayIcon)
 	systray.SetTooltip("Sussurro")
 
+	mCancel := systray.AddMenuItem("Cancel Dictation", "Discard the recording or transcription in progress")
+	mCancel.Disable()
+	trayCancel.Store(mCancel)
+	mHandsFree := systray.AddMenuItemCheckbox("Hands-free Dictation", "Listen continuously and send each utterance when you pause", false)
+	if m.onHandsFree == nil {
+		mHandsFree.Hide()
+	}
+	trayHandsFree.Store(mHandsFree)
 	mSettings := systray.AddMenuItem("Open Settings", "Open the settings window")
 	systray.AddSeparator()
 	mQuit := systray.AddMenuItem("Quit", "Exit Sussurro")
@@ -29,6 +45,12 @@ func (m *Manager) onTrayReady() {
 	go func() {
 		for {
 			select {
+			case <-mCancel.ClickedCh:
+				m.cancelDictation()
+
+			case <-mHandsFree.ClickedCh:
+				m.toggleHandsFree(!mHandsFree.Checked())
+
 			case <-mSettings.ClickedCh:
 				m.settings.Show()
 
@@ -49,9 +71,54 @@ func (m *Manager) onTrayExit() {
 
 // updateTrayIcon swaps the tray icon based on recording state.
 func (m *Manager) updateTrayIcon(state AppState) {
-	if state == StateRecording {
+	if state == StateRecording || state == StateListening {
 		systray.SetIcon(trayIconRec)
 	} else {
 		systray.SetIcon(trayIcon)
 	}
 }
+
+// updateTrayCancel enables the Cancel Dictation item while there is a
+// dictation to cancel.
+func (m *Manager) updateTrayCancel(state AppState) {
+	item := trayCancel.Load()
+	if item == nil {
+		return
+	}
+	if state == StateIdle {
+		item.Disable()
+	} else {
+		item.Enable()
+	}
+}
+
+// updateTrayHandsFree checks the Hands-free Dictation item while hands-free
+// dictation is on.
+func (m *Manager) updateTrayHandsFree(state AppState) {
+	item := trayHandsFree.Load()
+	if item == nil {
+		return
+	}
+	if state == StateListening {
+		item.Check()
+	} else {
+		item.Uncheck()
+	}
+}
+
+// maxTooltipRunes caps the partial transcript shown in the tray tooltip.
+const maxTooltipRunes = 80
+
+// updateTrayTooltip shows the tail of the in-progress transcript in the tray
+// tooltip, or restores the default tooltip when text is empty.
+func (m *Manager) updateTrayTooltip(text string) {
+	if text == "" {
+		systray.SetTooltip("Sussurro")
+		return
+	}
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) > maxTooltipRunes {
+		runes = append([]rune("…"), runes[len(runes)-maxTooltipRunes:]...)
+	}
+	systray.SetTooltip("Sussurro: " + string(runes))
+}
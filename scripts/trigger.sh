@@ -1,15 +1,23 @@
 #!/bin/bash
 # Trigger script for Sussurro on Wayland
-# Bind this script to your keyboard shortcut in your DE settings
+# Bind this script to your keyboard shortcut in your DE settings.
+#
+# Usage: trigger.sh [start|stop|toggle|press|release|cancel|status|version]   (default: toggle)
 
 SOCKET="${XDG_RUNTIME_DIR:-/tmp}/sussurro.sock"
+CMD="${1:-toggle}"
 
 if [ ! -S "$SOCKET" ]; then
     notify-send "Sussurro" "Sussurro is not running"
     exit 1
 fi
 
-echo "toggle" | nc -U "$SOCKET" 2>/dev/null || {
+# Prefer the built-in client when sussurro is on PATH
+if command -v sussurro >/dev/null 2>&1; then
+    exec sussurro ctl "$CMD" >/dev/null
+fi
+
+echo "$CMD" | nc -U "$SOCKET" 2>/dev/null || {
     # Fallback if nc is not available
-    echo "toggle" | socat - UNIX-CONNECT:"$SOCKET" 2>/dev/null
+    echo "$CMD" | socat - UNIX-CONNECT:"$SOCKET" 2>/dev/null
 }